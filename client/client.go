@@ -0,0 +1,240 @@
+// Package client is a Go client of the basichttpdebugger dashboard api for
+// CI and integration tests, it waits for captured requests and checks
+// expectations.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const maxWaitTimeout = 10 * time.Minute
+
+// sentinel errors.
+var (
+	ErrTimeout          = errors.New("no matching request within timeout")
+	ErrUnexpectedStatus = errors.New("unexpected status")
+)
+
+// expectation statuses.
+const (
+	StatusMet    = "met"
+	StatusMissed = "missed"
+)
+
+// Request is a captured request.
+type Request = requeststore.Request
+
+// Match selects requests, zero value matches all requests.
+type Match struct {
+	Method    string   // comma separated methods
+	Path      string   // glob, e.g. "/hooks/*"
+	PathRegex string   // regular expression
+	Headers   []string // "Name" or "Name: value" (substring, case-insensitive)
+	Body      string   // substring of body
+	JSON      []string // "$.path" or "$.path=value"
+	Direction string   // "captured" or "outbound"
+}
+
+func (m Match) values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	set("method", m.Method)
+	set("path", m.Path)
+	set("pathRegex", m.PathRegex)
+	set("body", m.Body)
+	set("direction", m.Direction)
+	for _, header := range m.Headers {
+		values.Add("header", header)
+	}
+	for _, path := range m.JSON {
+		values.Add("json", path)
+	}
+
+	return values
+}
+
+// Expectation expects Count (default 1) requests matching Match.
+type Expectation struct {
+	Name  string
+	Match Match
+	Count int
+}
+
+// ExpectationResult is the state of an expectation.
+type ExpectationResult struct {
+	Name    string              `json:"name"`
+	Match   map[string][]string `json:"match"`
+	Count   int                 `json:"count"`
+	Status  string              `json:"status"`  // StatusMet or StatusMissed
+	Matched []string            `json:"matched"` // ids of matching requests, oldest first
+}
+
+// Report reports registered expectations.
+type Report struct {
+	Met          bool                `json:"met"` // all expectations are met
+	Expectations []ExpectationResult `json:"expectations"`
+	Unexpected   []string            `json:"unexpected"` // ids of captured requests matching no expectation
+}
+
+// Client represents a dashboard api client.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	username   string
+	password   string
+	token      string
+}
+
+// Option represents option function type.
+type Option func(*Client)
+
+// WithHTTPClient sets the http client, http.DefaultClient by default.
+func WithHTTPClient(c *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = c
+	}
+}
+
+// WithBasicAuth sets basic auth credentials of the dashboard.
+func WithBasicAuth(username, password string) Option {
+	return func(client *Client) {
+		client.username = username
+		client.password = password
+	}
+}
+
+// WithToken sets the bearer token of the dashboard.
+func WithToken(token string) Option {
+	return func(client *Client) {
+		client.token = token
+	}
+}
+
+// New creates a new client of the dashboard at baseURL, e.g.
+// "http://localhost:9003" or "http://localhost:9003/bins/alice" for a bin.
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// Wait returns the first request matching match stored after sequence
+// number after, zero includes all stored requests. Pass Seq of the previous
+// result to wait for the next one. Wait blocks until ctx is done, or for 30
+// seconds if ctx has no deadline, and returns ErrTimeout if no request
+// matches.
+func (c *Client) Wait(ctx context.Context, match Match, after uint64) (Request, error) {
+	query := match.values()
+	query.Set("after", strconv.FormatUint(after, 10))
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) > 0 {
+		query.Set("timeout", min(time.Until(deadline), maxWaitTimeout).String())
+	}
+
+	var req Request
+	err := c.do(ctx, http.MethodGet, "/api/wait?"+query.Encode(), nil, http.StatusOK, &req)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// the deadline passed before the server timed out
+		return req, fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	}
+
+	return req, err
+}
+
+// Expect registers expectations, requests stored afterwards are matched.
+func (c *Client) Expect(ctx context.Context, expectations ...Expectation) error {
+	payload := make([]map[string]any, len(expectations))
+	for i, e := range expectations {
+		payload[i] = map[string]any{"name": e.Name, "match": e.Match.values(), "count": e.Count}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal expectations: %w", err)
+	}
+
+	return c.do(ctx, http.MethodPost, "/api/expectations", body, http.StatusCreated, nil)
+}
+
+// Expectations reports registered expectations, waiting up to wait for all
+// of them to be met if wait is positive.
+func (c *Client) Expectations(ctx context.Context, wait time.Duration) (Report, error) {
+	target := "/api/expectations"
+	if wait > 0 {
+		target += "?wait=" + min(wait, maxWaitTimeout).String()
+	}
+
+	var report Report
+	err := c.do(ctx, http.MethodGet, target, nil, http.StatusOK, &report)
+
+	return report, err
+}
+
+// ResetExpectations removes registered expectations.
+func (c *Client) ResetExpectations(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, "/api/expectations", nil, http.StatusNoContent, nil)
+}
+
+// do sends an api request, decodes the response into out if not nil.
+func (c *Client) do(ctx context.Context, method, target string, body []byte, status int, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, target, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusRequestTimeout {
+		return ErrTimeout
+	}
+	if resp.StatusCode != status {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return fmt.Errorf("%w: %s: %s", ErrUnexpectedStatus, resp.Status, bytes.TrimSpace(message))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/client"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+	"github.com/vbyazilim/basichttpdebugger/internal/webui"
+)
+
+func newServer(t *testing.T, options ...webui.Option) (*requeststore.Store, string) {
+	t.Helper()
+
+	store := requeststore.New(50)
+	w := webui.New(store, ":9003", ":9002", options...)
+	handler, err := w.Handler()
+	require.NoError(t, err)
+	server := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		_ = w.Stop()
+		server.Close()
+	})
+
+	return store, server.URL
+}
+
+func TestClient_Wait(t *testing.T) {
+	store, url := newServer(t)
+	c := client.New(url + "/")
+
+	store.Add(requeststore.Request{ID: "opened", Method: "POST", URL: "/hook", Body: `{"action": "opened"}`})
+
+	t.Run("returns a stored matching request", func(t *testing.T) {
+		req, err := c.Wait(context.Background(), client.Match{Method: "POST", JSON: []string{"$.action=opened"}}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "opened", req.ID)
+	})
+
+	t.Run("waits for the next matching request", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			store.Add(requeststore.Request{ID: "closed", Method: "POST", URL: "/hook", Body: `{"action": "closed"}`})
+		}()
+
+		req, err := c.Wait(ctx, client.Match{Path: "/hook"}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "closed", req.ID)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := c.Wait(ctx, client.Match{Method: "DELETE"}, 0)
+		require.ErrorIs(t, err, client.ErrTimeout)
+	})
+
+	t.Run("returns server errors", func(t *testing.T) {
+		_, err := c.Wait(context.Background(), client.Match{PathRegex: "("}, 0)
+		require.ErrorIs(t, err, client.ErrUnexpectedStatus)
+		assert.Contains(t, err.Error(), "400 Bad Request")
+	})
+}
+
+func TestClient_Expectations(t *testing.T) {
+	store, url := newServer(t, webui.WithCredentials(webui.Credential{Token: "secret"}))
+	ctx := context.Background()
+
+	_, err := client.New(url).Expectations(ctx, 0)
+	require.ErrorIs(t, err, client.ErrUnexpectedStatus)
+
+	c := client.New(url, client.WithToken("secret"), client.WithHTTPClient(&http.Client{}))
+
+	require.NoError(t, c.Expect(ctx,
+		client.Expectation{Name: "github", Match: client.Match{Headers: []string{"X-GitHub-Event: push"}}},
+		client.Expectation{Name: "twice", Match: client.Match{Method: "PUT"}, Count: 2},
+	))
+
+	store.Add(requeststore.Request{ID: "push", Method: "POST", Headers: map[string]string{"X-GitHub-Event": "push"}})
+	store.Add(requeststore.Request{ID: "put", Method: "PUT"})
+	store.Add(requeststore.Request{ID: "other", Method: "GET"})
+
+	report, err := c.Expectations(ctx, 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.False(t, report.Met)
+	require.Len(t, report.Expectations, 2)
+	assert.Equal(t, client.StatusMet, report.Expectations[0].Status)
+	assert.Equal(t, []string{"push"}, report.Expectations[0].Matched)
+	assert.Equal(t, client.StatusMissed, report.Expectations[1].Status)
+	assert.Equal(t, 2, report.Expectations[1].Count)
+	assert.Equal(t, []string{"other"}, report.Unexpected)
+
+	store.Add(requeststore.Request{ID: "put-2", Method: "PUT"})
+
+	report, err = c.Expectations(ctx, time.Second)
+	require.NoError(t, err)
+	assert.True(t, report.Met)
+
+	require.NoError(t, c.ResetExpectations(ctx))
+
+	report, err = c.Expectations(ctx, 0)
+	require.NoError(t, err)
+	assert.True(t, report.Met)
+	assert.Empty(t, report.Expectations)
+}
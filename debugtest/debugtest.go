@@ -0,0 +1,236 @@
+// Package debugtest starts a basichttpdebugger server inside tests, like
+// net/http/httptest does for handlers:
+//
+//	s, err := debugtest.New()
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	t.Cleanup(s.Close)
+//
+//	// point the code under test to s.URL, then
+//	requests := s.Requests()
+package debugtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/httpserver"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+	"github.com/vbyazilim/basichttpdebugger/internal/writerutils"
+)
+
+const defMaxRequests = 1000
+
+// Request is a captured request.
+type Request = requeststore.Request
+
+// Response is the response of captured requests.
+type Response = bin.Response
+
+// Server is a debug server listening on a random local port.
+type Server struct {
+	URL string // base url, e.g. "http://127.0.0.1:54321"
+
+	server        *httpserver.DebugServer
+	store         *requeststore.Store
+	mu            sync.Mutex
+	conns         map[net.Conn]http.ConnState
+	done          chan struct{}
+	output        io.Writer
+	response      *Response
+	maxRequests   int
+	listenAddr    string
+	serverOptions []httpserver.Option
+}
+
+// Option represents option function type.
+type Option func(*Server)
+
+// WithOutput prints request tables to w, e.g. os.Stdout, output is discarded
+// by default.
+func WithOutput(w io.Writer) Option {
+	return func(s *Server) {
+		s.output = w
+	}
+}
+
+// WithResponse sets the response of captured requests, see SetResponse.
+func WithResponse(resp Response) Option {
+	return func(s *Server) {
+		s.response = &resp
+	}
+}
+
+// WithMaxRequests sets the number of stored requests, oldest are evicted
+// first. Default is 1000.
+func WithMaxRequests(n int) Option {
+	return func(s *Server) {
+		s.maxRequests = n
+	}
+}
+
+// WithListenAddr sets the listen address, default is "127.0.0.1:0", a
+// random local port.
+func WithListenAddr(addr string) Option {
+	return func(s *Server) {
+		s.listenAddr = addr
+	}
+}
+
+// WithHMAC validates HMAC signatures of request bodies sent in given header,
+// e.g. "X-Hub-Signature-256".
+func WithHMAC(secret, headerName string) Option {
+	return func(s *Server) {
+		s.serverOptions = append(s.serverOptions,
+			httpserver.WithHMACSecret(secret),
+			httpserver.WithHMACHeaderName(headerName),
+		)
+	}
+}
+
+// WithSecretToken validates the secret token sent in given header, e.g.
+// "X-Gitlab-Token".
+func WithSecretToken(token, headerName string) Option {
+	return func(s *Server) {
+		s.serverOptions = append(s.serverOptions,
+			httpserver.WithSecretToken(token),
+			httpserver.WithSecretTokenHeaderName(headerName),
+		)
+	}
+}
+
+// New starts a new server, Close must be called to stop it.
+func New(options ...Option) (*Server, error) {
+	s := &Server{
+		done:        make(chan struct{}),
+		output:      io.Discard,
+		maxRequests: defMaxRequests,
+		listenAddr:  "127.0.0.1:0",
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.maxRequests <= 0 {
+		return nil, fmt.Errorf("invalid max requests %d: %w", s.maxRequests, httpserver.ErrInvalidValue)
+	}
+
+	listener, err := net.Listen("tcp", s.listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("listen error: %w", err)
+	}
+
+	s.store = requeststore.New(s.maxRequests)
+	s.server, err = httpserver.New(append([]httpserver.Option{
+		httpserver.WithListenAddr(listener.Addr().String()),
+		httpserver.WithOutput(writerutils.NopCloser(s.output)),
+		httpserver.WithStore(s.store),
+		httpserver.WithResponse(s.response),
+	}, s.serverOptions...)...)
+	if err != nil {
+		_ = listener.Close()
+
+		return nil, fmt.Errorf("server error: %w", err)
+	}
+
+	s.URL = "http://" + listener.Addr().String()
+	s.conns = make(map[net.Conn]http.ConnState)
+	s.server.HTTPServer.ConnState = s.trackConn
+
+	go func() {
+		defer close(s.done)
+
+		_ = s.server.Serve(listener)
+	}()
+
+	return s, nil
+}
+
+// Requests returns captured requests, oldest first.
+func (s *Server) Requests() []Request {
+	requests := s.store.GetAll()
+	slices.Reverse(requests)
+
+	return requests
+}
+
+// Last returns the last captured request.
+func (s *Server) Last() (Request, bool) {
+	requests := s.store.GetAll()
+	if len(requests) == 0 {
+		return Request{}, false
+	}
+
+	return requests[0], true
+}
+
+// Reset removes captured requests.
+func (s *Server) Reset() {
+	s.store.Clear()
+}
+
+// SetResponse sets the response of captured requests, zero value restores
+// the default "OK" response.
+func (s *Server) SetResponse(resp Response) {
+	s.server.SetResponse(&resp)
+}
+
+// Wait returns the first captured request, including already captured ones,
+// that satisfies match (nil matches all), waiting until ctx is done.
+func (s *Server) Wait(ctx context.Context, match func(Request) bool) (Request, error) {
+	ch := s.store.Subscribe()
+	defer s.store.Unsubscribe(ch)
+
+	var after uint64
+	for {
+		for _, req := range s.store.Since(after) {
+			after = req.Seq
+			if match == nil || match(req) {
+				return req, nil
+			}
+		}
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return Request{}, fmt.Errorf("wait error: %w", ctx.Err())
+		}
+	}
+}
+
+// Close shuts down the server, waits for active requests and removes
+// spooled request bodies.
+func (s *Server) Close() {
+	// http.Server.Shutdown waits up to 5 seconds for connections without
+	// requests, e.g. ones dialed ahead by http.Transport
+	s.mu.Lock()
+	for conn, state := range s.conns {
+		if state == http.StateNew || state == http.StateIdle {
+			_ = conn.Close()
+		}
+	}
+	s.mu.Unlock()
+
+	_ = s.server.Stop()
+	<-s.done
+}
+
+func (s *Server) trackConn(conn net.Conn, state http.ConnState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch state {
+	case http.StateHijacked, http.StateClosed:
+		delete(s.conns, conn)
+	default:
+		s.conns[conn] = state
+	}
+}
@@ -0,0 +1,156 @@
+package debugtest_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/debugtest"
+)
+
+func post(t *testing.T, url, body string, headers ...string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	return resp
+}
+
+func TestServer(t *testing.T) {
+	s, err := debugtest.New()
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	assert.True(t, strings.HasPrefix(s.URL, "http://127.0.0.1:"))
+
+	_, ok := s.Last()
+	assert.False(t, ok)
+
+	resp := post(t, s.URL+"/hooks/github?x=1", `{"action": "opened"}`)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "OK\n", string(body))
+
+	post(t, s.URL+"/hooks/stripe", `{"type": "charge.succeeded"}`)
+
+	requests := s.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "/hooks/github?x=1", requests[0].URL)
+	assert.Equal(t, `{"action": "opened"}`, requests[0].Body)
+	assert.Equal(t, "/hooks/stripe", requests[1].URL)
+
+	last, ok := s.Last()
+	require.True(t, ok)
+	assert.Equal(t, requests[1].ID, last.ID)
+
+	s.Reset()
+	assert.Empty(t, s.Requests())
+}
+
+func TestServer_SetResponse(t *testing.T) {
+	s, err := debugtest.New(debugtest.WithResponse(debugtest.Response{
+		Status:  http.StatusAccepted,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    `{"ok": true}`,
+	}))
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	resp := post(t, s.URL, `{}`)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"ok": true}`, string(body))
+
+	s.SetResponse(debugtest.Response{Status: http.StatusInternalServerError})
+	assert.Equal(t, http.StatusInternalServerError, post(t, s.URL, `{}`).StatusCode)
+
+	s.SetResponse(debugtest.Response{})
+	assert.Equal(t, http.StatusOK, post(t, s.URL, `{}`).StatusCode)
+	assert.Len(t, s.Requests(), 3)
+}
+
+func TestServer_Wait(t *testing.T) {
+	s, err := debugtest.New()
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		for _, path := range []string{"/a", "/b"} {
+			req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, s.URL+path, nil)
+			if resp, err := http.DefaultClient.Do(req); err == nil {
+				_ = resp.Body.Close()
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := s.Wait(ctx, func(r debugtest.Request) bool { return r.URL == "/b" })
+	require.NoError(t, err)
+	assert.Equal(t, "/b", req.URL)
+
+	req, err = s.Wait(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/a", req.URL)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = s.Wait(ctx, func(r debugtest.Request) bool { return r.URL == "/c" })
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServer_options(t *testing.T) {
+	var out bytes.Buffer
+
+	s, err := debugtest.New(
+		debugtest.WithOutput(&out),
+		debugtest.WithMaxRequests(1),
+		debugtest.WithHMAC("secret", "X-Signature"),
+	)
+	require.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`{"n": 2}`))
+
+	post(t, s.URL, `{"n": 1}`)
+	post(t, s.URL, `{"n": 2}`, "X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	requests := s.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, `{"n": 2}`, requests[0].Body)
+	require.NotNil(t, requests[0].Signature)
+	assert.True(t, *requests[0].Signature.HMAC)
+
+	s.Close()
+	assert.Contains(t, out.String(), "Basic HTTP Debugger")
+
+	_, err = debugtest.New(debugtest.WithMaxRequests(0))
+	require.Error(t, err)
+
+	_, err = debugtest.New(debugtest.WithListenAddr("invalid"))
+	require.Error(t, err)
+}
@@ -7,6 +7,7 @@ require (
 	github.com/jedib0t/go-pretty/v6 v6.7.8
 	github.com/stretchr/testify v1.11.1
 	golang.org/x/term v0.40.0
+	golang.org/x/text v0.32.0
 )
 
 require (
@@ -18,7 +19,6 @@ require (
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/sys v0.41.0 // indirect
-	golang.org/x/text v0.32.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20200227125254-8fa46927fb4f // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,417 @@
+package authorization
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sentinel errors.
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+	ErrNoMatchingKey        = errors.New("no matching key")
+	ErrInvalidSignature     = errors.New("invalid signature")
+	ErrInvalidKey           = errors.New("invalid key")
+)
+
+const (
+	schemeBasic  = "Basic"
+	schemeBearer = "Bearer"
+
+	maskedPassword    = "********"
+	jwtParts          = 3
+	uncompressedPoint = 0x04 // SEC 1 uncompressed elliptic curve point prefix
+)
+
+// TimeClaim represents a registered date claim in human readable form.
+type TimeClaim struct {
+	Name  string    `json:"name"`
+	Time  time.Time `json:"time"`
+	Human string    `json:"human"`
+}
+
+// JWT represents a decoded JSON Web Token.
+type JWT struct {
+	Header      map[string]any `json:"header"`
+	Claims      map[string]any `json:"claims"`
+	TimeClaims  []TimeClaim    `json:"timeClaims,omitempty"`
+	Verified    *bool          `json:"verified,omitempty"`
+	VerifiedBy  string         `json:"verifiedBy,omitempty"`
+	VerifyError string         `json:"verifyError,omitempty"`
+}
+
+// Info holds decoded Authorization header information.
+type Info struct {
+	Scheme   string `json:"scheme"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	JWT      *JWT   `json:"jwt,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// Verifier verifies JWT signatures with an HMAC secret and/or a JWKS key set.
+type Verifier struct {
+	hmacSecret []byte
+	keys       []jsonWebKey
+}
+
+// NewVerifier creates a signature verifier. Both arguments are optional, nil
+// is returned if none of them is given.
+func NewVerifier(hmacSecret, jwksFile string) (*Verifier, error) {
+	if hmacSecret == "" && jwksFile == "" {
+		return nil, nil
+	}
+
+	v := &Verifier{hmacSecret: []byte(hmacSecret)}
+
+	if jwksFile == "" {
+		return v, nil
+	}
+
+	content, err := os.ReadFile(filepath.Clean(jwksFile))
+	if err != nil {
+		return nil, fmt.Errorf("jwks read error: %w", err)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.Unmarshal(content, &jwks); err != nil {
+		return nil, fmt.Errorf("jwks parse error: %w", err)
+	}
+	v.keys = jwks.Keys
+
+	return v, nil
+}
+
+// Parse decodes given Authorization header value. JWT signatures are verified
+// when verifier is not nil, date claims are described relative to now.
+func Parse(value string, verifier *Verifier, now time.Time) *Info {
+	if value == "" {
+		return nil
+	}
+
+	scheme, credentials, _ := strings.Cut(strings.TrimSpace(value), " ")
+	credentials = strings.TrimSpace(credentials)
+	info := &Info{Scheme: scheme}
+
+	switch {
+	case strings.EqualFold(scheme, schemeBasic):
+		info.Scheme = schemeBasic
+
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			info.Error = "invalid base64 credentials"
+
+			return info
+		}
+
+		username, _, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			info.Error = "credentials are not in username:password form"
+		}
+		info.Username = username
+		info.Password = maskedPassword
+	case strings.EqualFold(scheme, schemeBearer):
+		info.Scheme = schemeBearer
+
+		if strings.Count(credentials, ".") != jwtParts-1 {
+			return info
+		}
+
+		token, err := parseJWT(credentials, verifier, now)
+		if err != nil {
+			info.Error = err.Error()
+
+			return info
+		}
+		info.JWT = token
+	}
+
+	return info
+}
+
+func parseJWT(token string, verifier *Verifier, now time.Time) (*JWT, error) {
+	parts := strings.Split(token, ".")
+
+	result := &JWT{}
+
+	if err := decodeSegment(parts[0], &result.Header); err != nil {
+		return nil, fmt.Errorf("jwt header decode error: %w", err)
+	}
+	if err := decodeSegment(parts[1], &result.Claims); err != nil {
+		return nil, fmt.Errorf("jwt claims decode error: %w", err)
+	}
+
+	for _, name := range []string{"exp", "iat", "nbf"} {
+		seconds, ok := result.Claims[name].(float64)
+		if !ok {
+			continue
+		}
+
+		ts := time.Unix(int64(seconds), 0).UTC()
+		result.TimeClaims = append(result.TimeClaims, TimeClaim{
+			Name:  name,
+			Time:  ts,
+			Human: describeTime(name, ts, now),
+		})
+	}
+
+	if verifier == nil {
+		return result, nil
+	}
+
+	alg, _ := result.Header["alg"].(string)
+	kid, _ := result.Header["kid"].(string)
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		result.setVerification(false, "", "signature is not valid base64url")
+
+		return result, nil
+	}
+
+	verifiedBy, err := verifier.verify(alg, kid, []byte(parts[0]+"."+parts[1]), signature)
+	if err != nil {
+		result.setVerification(false, verifiedBy, err.Error())
+
+		return result, nil
+	}
+	result.setVerification(true, verifiedBy, "")
+
+	return result, nil
+}
+
+func (j *JWT) setVerification(ok bool, by, errMessage string) {
+	j.Verified = &ok
+	j.VerifiedBy = by
+	j.VerifyError = errMessage
+}
+
+func decodeSegment(segment string, target *map[string]any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return fmt.Errorf("base64 decode error: %w", err)
+	}
+
+	return json.Unmarshal(data, target)
+}
+
+func describeTime(name string, ts, now time.Time) string {
+	diff := ts.Sub(now).Round(time.Second)
+	when := "in " + diff.String()
+	if diff < 0 {
+		when = (-diff).String() + " ago"
+	}
+
+	desc := ts.Format(time.RFC3339) + " (" + when + ")"
+	switch {
+	case name == "exp" && !ts.After(now):
+		desc += " expired"
+	case name == "nbf" && ts.After(now):
+		desc += " not yet valid"
+	}
+
+	return desc
+}
+
+func (v *Verifier) verify(alg, kid string, signingInput, signature []byte) (string, error) {
+	if strings.HasPrefix(alg, "HS") {
+		hashFunc, err := hashFor(alg)
+		if err != nil {
+			return "", err
+		}
+
+		if len(v.hmacSecret) > 0 {
+			return "hmac secret", verifyHMAC(hashFunc, v.hmacSecret, signingInput, signature)
+		}
+
+		for _, key := range v.keys {
+			if key.Kty != "oct" || (kid != "" && key.Kid != kid) {
+				continue
+			}
+
+			secret, errKey := base64.RawURLEncoding.DecodeString(key.K)
+			if errKey != nil {
+				return "jwks kid=" + key.Kid, ErrInvalidKey
+			}
+
+			return "jwks kid=" + key.Kid, verifyHMAC(hashFunc, secret, signingInput, signature)
+		}
+
+		return "", ErrNoMatchingKey
+	}
+
+	kty := ""
+	switch {
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		kty = "RSA"
+	case strings.HasPrefix(alg, "ES"):
+		kty = "EC"
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	for _, key := range v.keys {
+		if key.Kty != kty || (kid != "" && key.Kid != kid) {
+			continue
+		}
+
+		by := "jwks kid=" + key.Kid
+		if kty == "RSA" {
+			return by, verifyRSA(alg, key, signingInput, signature)
+		}
+
+		return by, verifyECDSA(alg, key, signingInput, signature)
+	}
+
+	return "", ErrNoMatchingKey
+}
+
+func hashFor(alg string) (crypto.Hash, error) {
+	if len(alg) < len("XX256") {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	}
+
+	return 0, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+}
+
+func newHash(h crypto.Hash) func() hash.Hash {
+	switch h {
+	case crypto.SHA384:
+		return sha512.New384
+	case crypto.SHA512:
+		return sha512.New
+	default:
+		return sha256.New
+	}
+}
+
+func digest(h crypto.Hash, data []byte) []byte {
+	hasher := newHash(h)()
+	_, _ = hasher.Write(data)
+
+	return hasher.Sum(nil)
+}
+
+func verifyHMAC(h crypto.Hash, secret, signingInput, signature []byte) error {
+	mac := hmac.New(newHash(h), secret)
+	_, _ = mac.Write(signingInput)
+
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func verifyRSA(alg string, key jsonWebKey, signingInput, signature []byte) error {
+	h, err := hashFor(alg)
+	if err != nil {
+		return err
+	}
+
+	n, errN := base64.RawURLEncoding.DecodeString(key.N)
+	e, errE := base64.RawURLEncoding.DecodeString(key.E)
+	if errN != nil || errE != nil || len(e) == 0 {
+		return ErrInvalidKey
+	}
+
+	publicKey := &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+
+	if strings.HasPrefix(alg, "PS") {
+		err = rsa.VerifyPSS(publicKey, h, digest(h, signingInput), signature, nil)
+	} else {
+		err = rsa.VerifyPKCS1v15(publicKey, h, digest(h, signingInput), signature)
+	}
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func verifyECDSA(alg string, key jsonWebKey, signingInput, signature []byte) error {
+	h, err := hashFor(alg)
+	if err != nil {
+		return err
+	}
+
+	// the curve is bound to the algorithm, keys of other curves are rejected
+	var curve elliptic.Curve
+	switch alg {
+	case "ES256":
+		curve = elliptic.P256()
+	case "ES384":
+		curve = elliptic.P384()
+	case "ES512":
+		curve = elliptic.P521()
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+	}
+	if key.Crv != curve.Params().Name {
+		return ErrInvalidKey
+	}
+
+	x, errX := base64.RawURLEncoding.DecodeString(key.X)
+	y, errY := base64.RawURLEncoding.DecodeString(key.Y)
+	if errX != nil || errY != nil {
+		return ErrInvalidKey
+	}
+
+	point := append([]byte{uncompressedPoint}, x...)
+	publicKey, err := ecdsa.ParseUncompressedPublicKey(curve, append(point, y...))
+	if err != nil {
+		return ErrInvalidKey
+	}
+
+	half := len(signature) / 2
+	if half != (curve.Params().BitSize+7)/8 || len(signature)%2 != 0 {
+		return ErrInvalidSignature
+	}
+
+	r := new(big.Int).SetBytes(signature[:half])
+	s := new(big.Int).SetBytes(signature[half:])
+	if !ecdsa.Verify(publicKey, digest(h, signingInput), r, s) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package authorization
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signedHS256(t *testing.T, secret string, claims map[string]any) string {
+	t.Helper()
+
+	input := encodeSegment(t, map[string]any{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(input))
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func writeJWKS(t *testing.T, keys ...map[string]string) string {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+
+	fname := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(fname, data, 0o600))
+
+	return fname
+}
+
+func TestParse(t *testing.T) {
+	now := time.Date(2026, 1, 23, 12, 0, 0, 0, time.UTC)
+
+	t.Run("returns nil for empty header", func(t *testing.T) {
+		assert.Nil(t, Parse("", nil, now))
+	})
+
+	t.Run("basic credentials mask password", func(t *testing.T) {
+		value := "Basic " + base64.StdEncoding.EncodeToString([]byte("vigo:s3cr3t"))
+
+		info := Parse(value, nil, now)
+		require.NotNil(t, info)
+		assert.Equal(t, "Basic", info.Scheme)
+		assert.Equal(t, "vigo", info.Username)
+		assert.Equal(t, maskedPassword, info.Password)
+		assert.NotContains(t, info.Password, "s3cr3t")
+		assert.Empty(t, info.Error)
+	})
+
+	t.Run("basic credentials with invalid base64", func(t *testing.T) {
+		info := Parse("basic !!!", nil, now)
+		require.NotNil(t, info)
+		assert.Equal(t, "Basic", info.Scheme)
+		assert.Equal(t, "invalid base64 credentials", info.Error)
+	})
+
+	t.Run("opaque bearer token", func(t *testing.T) {
+		info := Parse("Bearer abcdef", nil, now)
+		require.NotNil(t, info)
+		assert.Equal(t, "Bearer", info.Scheme)
+		assert.Nil(t, info.JWT)
+	})
+
+	t.Run("jwt claims and time claims", func(t *testing.T) {
+		token := signedHS256(t, "secret", map[string]any{
+			"sub": "42",
+			"exp": now.Add(-time.Hour).Unix(),
+			"iat": now.Add(-2 * time.Hour).Unix(),
+			"nbf": now.Add(time.Hour).Unix(),
+		})
+
+		info := Parse("Bearer "+token, nil, now)
+		require.NotNil(t, info)
+		require.NotNil(t, info.JWT)
+		assert.Equal(t, "HS256", info.JWT.Header["alg"])
+		assert.Equal(t, "42", info.JWT.Claims["sub"])
+		assert.Nil(t, info.JWT.Verified)
+
+		require.Len(t, info.JWT.TimeClaims, 3)
+		assert.Equal(t, "exp", info.JWT.TimeClaims[0].Name)
+		assert.Equal(t, "2026-01-23T11:00:00Z (1h0m0s ago) expired", info.JWT.TimeClaims[0].Human)
+		assert.Equal(t, "iat", info.JWT.TimeClaims[1].Name)
+		assert.Equal(t, "2026-01-23T10:00:00Z (2h0m0s ago)", info.JWT.TimeClaims[1].Human)
+		assert.Equal(t, "nbf", info.JWT.TimeClaims[2].Name)
+		assert.Equal(t, "2026-01-23T13:00:00Z (in 1h0m0s) not yet valid", info.JWT.TimeClaims[2].Human)
+	})
+
+	t.Run("malformed jwt", func(t *testing.T) {
+		info := Parse("Bearer a.b.c", nil, now)
+		require.NotNil(t, info)
+		assert.Nil(t, info.JWT)
+		assert.Contains(t, info.Error, "jwt header decode error")
+	})
+
+	t.Run("hmac signature verification", func(t *testing.T) {
+		token := signedHS256(t, "secret", map[string]any{"sub": "42"})
+
+		verifier, err := NewVerifier("secret", "")
+		require.NoError(t, err)
+
+		info := Parse("Bearer "+token, verifier, now)
+		require.NotNil(t, info.JWT)
+		require.NotNil(t, info.JWT.Verified)
+		assert.True(t, *info.JWT.Verified)
+		assert.Equal(t, "hmac secret", info.JWT.VerifiedBy)
+
+		verifier, err = NewVerifier("wrong", "")
+		require.NoError(t, err)
+
+		info = Parse("Bearer "+token, verifier, now)
+		require.NotNil(t, info.JWT.Verified)
+		assert.False(t, *info.JWT.Verified)
+		assert.Equal(t, ErrInvalidSignature.Error(), info.JWT.VerifyError)
+	})
+
+	t.Run("rsa signature verification with jwks", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		input := encodeSegment(t, map[string]any{"alg": "RS256", "kid": "k1"}) + "." +
+			encodeSegment(t, map[string]any{"sub": "42"})
+		hashed := sha256.Sum256([]byte(input))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+		require.NoError(t, err)
+		token := input + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+		jwksFile := writeJWKS(t, map[string]string{
+			"kty": "RSA",
+			"kid": "k1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+
+		verifier, err := NewVerifier("", jwksFile)
+		require.NoError(t, err)
+
+		info := Parse("Bearer "+token, verifier, now)
+		require.NotNil(t, info.JWT)
+		require.NotNil(t, info.JWT.Verified)
+		assert.True(t, *info.JWT.Verified, info.JWT.VerifyError)
+		assert.Equal(t, "jwks kid=k1", info.JWT.VerifiedBy)
+	})
+
+	t.Run("ecdsa signature verification with jwks", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		input := encodeSegment(t, map[string]any{"alg": "ES256"}) + "." +
+			encodeSegment(t, map[string]any{"sub": "42"})
+		hashed := sha256.Sum256([]byte(input))
+		r, s, err := ecdsa.Sign(rand.Reader, key, hashed[:])
+		require.NoError(t, err)
+
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		token := input + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+		point, err := key.PublicKey.Bytes()
+		require.NoError(t, err)
+
+		jwksFile := writeJWKS(t, map[string]string{
+			"kty": "EC",
+			"kid": "ec1",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(point[1:33]),
+			"y":   base64.RawURLEncoding.EncodeToString(point[33:]),
+		})
+
+		verifier, err := NewVerifier("", jwksFile)
+		require.NoError(t, err)
+
+		info := Parse("Bearer "+token, verifier, now)
+		require.NotNil(t, info.JWT)
+		require.NotNil(t, info.JWT.Verified)
+		assert.True(t, *info.JWT.Verified, info.JWT.VerifyError)
+		assert.Equal(t, "jwks kid=ec1", info.JWT.VerifiedBy)
+	})
+
+	t.Run("ecdsa key of another curve than alg", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+
+		input := encodeSegment(t, map[string]any{"alg": "ES256"}) + "." +
+			encodeSegment(t, map[string]any{"sub": "42"})
+		hashed := sha256.Sum256([]byte(input))
+		r, s, err := ecdsa.Sign(rand.Reader, key, hashed[:])
+		require.NoError(t, err)
+
+		signature := make([]byte, 96)
+		r.FillBytes(signature[:48])
+		s.FillBytes(signature[48:])
+		token := input + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+		point, err := key.PublicKey.Bytes()
+		require.NoError(t, err)
+
+		jwksFile := writeJWKS(t, map[string]string{
+			"kty": "EC",
+			"kid": "ec1",
+			"crv": "P-384",
+			"x":   base64.RawURLEncoding.EncodeToString(point[1:49]),
+			"y":   base64.RawURLEncoding.EncodeToString(point[49:]),
+		})
+
+		verifier, err := NewVerifier("", jwksFile)
+		require.NoError(t, err)
+
+		info := Parse("Bearer "+token, verifier, now)
+		require.NotNil(t, info.JWT)
+		require.NotNil(t, info.JWT.Verified)
+		assert.False(t, *info.JWT.Verified)
+		assert.Equal(t, ErrInvalidKey.Error(), info.JWT.VerifyError)
+	})
+
+	t.Run("no matching key in jwks", func(t *testing.T) {
+		jwksFile := writeJWKS(t, map[string]string{"kty": "RSA", "kid": "other", "n": "AQ", "e": "AQAB"})
+
+		verifier, err := NewVerifier("", jwksFile)
+		require.NoError(t, err)
+
+		input := encodeSegment(t, map[string]any{"alg": "RS256", "kid": "k1"}) + "." +
+			encodeSegment(t, map[string]any{"sub": "42"})
+
+		info := Parse("Bearer "+input+".c2ln", verifier, now)
+		require.NotNil(t, info.JWT)
+		require.NotNil(t, info.JWT.Verified)
+		assert.False(t, *info.JWT.Verified)
+		assert.Equal(t, ErrNoMatchingKey.Error(), info.JWT.VerifyError)
+	})
+}
+
+func TestNewVerifier(t *testing.T) {
+	t.Run("returns nil without secret and jwks", func(t *testing.T) {
+		verifier, err := NewVerifier("", "")
+		require.NoError(t, err)
+		assert.Nil(t, verifier)
+	})
+
+	t.Run("returns error for missing jwks file", func(t *testing.T) {
+		_, err := NewVerifier("", "/nonexistent/jwks.json")
+		assert.ErrorContains(t, err, "jwks read error")
+	})
+
+	t.Run("returns error for invalid jwks file", func(t *testing.T) {
+		fname := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, os.WriteFile(fname, []byte("invalid"), 0o600))
+
+		_, err := NewVerifier("", fname)
+		assert.ErrorContains(t, err, "jwks parse error")
+	})
+}
@@ -0,0 +1,239 @@
+package bin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+// PathPrefix is the capture url prefix of bins, requests sent to
+// "/b/{token}/..." are captured by bin {token}.
+const PathPrefix = "/b/"
+
+const tokenBytes = 8
+
+// sentinel errors.
+var (
+	ErrInvalidToken  = errors.New("invalid bin token")
+	ErrInvalidConfig = errors.New("invalid bin config")
+	ErrBinExists     = errors.New("bin already exists")
+	ErrTooManyBins   = errors.New("too many bins")
+)
+
+var tokenPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{2,62}$`)
+
+// Response is the response returned to requests captured by a bin, zero
+// values fall back to the default "200 OK" text response.
+type Response struct {
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Config holds user settings of a bin. Secret token and HMAC settings
+// replace the server wide ones for requests captured by the bin.
+type Config struct {
+	Token                 string    `json:"token,omitempty"` // random if empty
+	Name                  string    `json:"name,omitempty"`
+	SecretToken           string    `json:"secretToken,omitempty"`
+	SecretTokenHeaderName string    `json:"secretTokenHeaderName,omitempty"`
+	HMACSecret            string    `json:"hmacSecret,omitempty"`
+	HMACHeaderName        string    `json:"hmacHeaderName,omitempty"`
+	Response              *Response `json:"response,omitempty"`
+}
+
+// Bin is an isolated capture target with its own request store.
+type Bin struct {
+	Config
+
+	Created time.Time           `json:"created"`
+	Store   *requeststore.Store `json:"-"`
+}
+
+// Registry holds bins.
+type Registry struct {
+	mu            sync.RWMutex
+	bins          map[string]*Bin
+	maxBins       int
+	maxRequests   int
+	evictHandlers []func(requeststore.Request)
+}
+
+// NewRegistry creates a registry of at most maxBins bins, each storing at
+// most maxRequests requests.
+func NewRegistry(maxBins, maxRequests int) *Registry {
+	return &Registry{
+		bins:        make(map[string]*Bin),
+		maxBins:     maxBins,
+		maxRequests: maxRequests,
+	}
+}
+
+// Create creates a new bin.
+func (r *Registry) Create(cfg Config) (*Bin, error) {
+	if cfg.Response != nil && cfg.Response.Status != 0 &&
+		(cfg.Response.Status < http.StatusContinue || cfg.Response.Status > 999) {
+		return nil, fmt.Errorf("%w: response status %d", ErrInvalidConfig, cfg.Response.Status)
+	}
+
+	if cfg.Token == "" {
+		token, err := newToken()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Token = token
+	}
+	if !tokenPattern.MatchString(cfg.Token) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidToken, cfg.Token)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.bins[cfg.Token]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrBinExists, cfg.Token)
+	}
+	if len(r.bins) >= r.maxBins {
+		return nil, fmt.Errorf("%w: max %d", ErrTooManyBins, r.maxBins)
+	}
+
+	b := &Bin{
+		Config:  cfg,
+		Created: time.Now().UTC(),
+		Store:   requeststore.New(r.maxRequests),
+	}
+	for _, fn := range r.evictHandlers {
+		b.Store.OnEvict(fn)
+	}
+	r.bins[cfg.Token] = b
+
+	return b, nil
+}
+
+// Get returns the bin with given token.
+func (r *Registry) Get(token string) (*Bin, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	b, ok := r.bins[token]
+
+	return b, ok
+}
+
+// List returns all bins, oldest first.
+func (r *Registry) List() []*Bin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bins := make([]*Bin, 0, len(r.bins))
+	for _, b := range r.bins {
+		bins = append(bins, b)
+	}
+	sort.Slice(bins, func(i, j int) bool {
+		if bins[i].Created.Equal(bins[j].Created) {
+			return bins[i].Token < bins[j].Token
+		}
+
+		return bins[i].Created.Before(bins[j].Created)
+	})
+
+	return bins
+}
+
+// Delete removes the bin with given token and clears its store. Returns
+// false if there is no such bin.
+func (r *Registry) Delete(token string) bool {
+	r.mu.Lock()
+	b, ok := r.bins[token]
+	delete(r.bins, token)
+	r.mu.Unlock()
+
+	if ok {
+		b.Store.Clear()
+	}
+
+	return ok
+}
+
+// OnEvict registers an evict handler on stores of all current and future
+// bins.
+func (r *Registry) OnEvict(fn func(requeststore.Request)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evictHandlers = append(r.evictHandlers, fn)
+	for _, b := range r.bins {
+		b.Store.OnEvict(fn)
+	}
+}
+
+// Resolve returns the bin of req and the request path without the bin
+// prefix. Bins are addressed with "/b/{token}/..." path prefix or with
+// "{token}." subdomain. An unknown token in path prefix returns
+// ErrInvalidToken, an unknown subdomain is not a bin request.
+func (r *Registry) Resolve(req *http.Request) (*Bin, string, error) {
+	if token, rest, ok := Split(req.URL.Path); ok {
+		b, found := r.Get(token)
+		if !found {
+			return nil, "", fmt.Errorf("%w: %q", ErrInvalidToken, token)
+		}
+
+		return b, rest, nil
+	}
+
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if label, _, ok := strings.Cut(host, "."); ok {
+		if b, found := r.Get(strings.ToLower(label)); found {
+			return b, req.URL.Path, nil
+		}
+	}
+
+	return nil, req.URL.Path, nil
+}
+
+// Split splits "/b/{token}/rest" path into token and "/rest".
+func Split(p string) (string, string, bool) {
+	after, ok := strings.CutPrefix(p, PathPrefix)
+	if !ok {
+		return "", "", false
+	}
+
+	token, rest, _ := strings.Cut(after, "/")
+	if token == "" {
+		return "", "", false
+	}
+
+	return token, "/" + rest, true
+}
+
+// Path returns capture path of given request uri in bin token, uri itself
+// if token is empty.
+func Path(token, uri string) string {
+	if token == "" {
+		return uri
+	}
+
+	return PathPrefix + token + uri
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("token generate error: %w", err)
+	}
+
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,121 @@
+package bin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+func TestRegistry_Create(t *testing.T) {
+	t.Run("creates bins with random and given tokens", func(t *testing.T) {
+		reg := bin.NewRegistry(10, 5)
+
+		random, err := reg.Create(bin.Config{Name: "stripe"})
+		require.NoError(t, err)
+		assert.Len(t, random.Token, 16)
+		assert.Equal(t, "stripe", random.Name)
+		assert.NotNil(t, random.Store)
+		assert.False(t, random.Created.IsZero())
+
+		named, err := reg.Create(bin.Config{Token: "alice-github"})
+		require.NoError(t, err)
+		assert.Equal(t, "alice-github", named.Token)
+
+		found, ok := reg.Get("alice-github")
+		assert.True(t, ok)
+		assert.Same(t, named, found)
+		assert.Len(t, reg.List(), 2)
+	})
+
+	t.Run("rejects invalid configs", func(t *testing.T) {
+		reg := bin.NewRegistry(1, 5)
+
+		_, err := reg.Create(bin.Config{Token: "Bad Token"})
+		require.ErrorIs(t, err, bin.ErrInvalidToken)
+
+		_, err = reg.Create(bin.Config{Response: &bin.Response{Status: 42}})
+		require.ErrorIs(t, err, bin.ErrInvalidConfig)
+
+		_, err = reg.Create(bin.Config{Token: "taken"})
+		require.NoError(t, err)
+
+		_, err = reg.Create(bin.Config{Token: "taken"})
+		require.ErrorIs(t, err, bin.ErrBinExists)
+
+		_, err = reg.Create(bin.Config{Token: "other"})
+		require.ErrorIs(t, err, bin.ErrTooManyBins)
+	})
+}
+
+func TestRegistry_Delete(t *testing.T) {
+	reg := bin.NewRegistry(10, 5)
+
+	var evicted []string
+	reg.OnEvict(func(req requeststore.Request) {
+		evicted = append(evicted, req.ID)
+	})
+
+	b, err := reg.Create(bin.Config{Token: "gone"})
+	require.NoError(t, err)
+	b.Store.Add(requeststore.Request{ID: "req-1"})
+
+	assert.True(t, reg.Delete("gone"))
+	assert.False(t, reg.Delete("gone"))
+	assert.Equal(t, []string{"req-1"}, evicted)
+	assert.Empty(t, reg.List())
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	reg := bin.NewRegistry(10, 5)
+	_, err := reg.Create(bin.Config{Token: "abc123"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		host    string
+		path    string
+		wantBin string
+		wantErr bool
+		rest    string
+	}{
+		{name: "path prefix", host: "localhost:9002", path: "/b/abc123/hooks/x", wantBin: "abc123", rest: "/hooks/x"},
+		{name: "path prefix root", host: "localhost:9002", path: "/b/abc123", wantBin: "abc123", rest: "/"},
+		{name: "subdomain", host: "ABC123.hooks.example.com", path: "/hooks", wantBin: "abc123", rest: "/hooks"},
+		{name: "unknown path token", host: "localhost:9002", path: "/b/nope/hooks", wantErr: true},
+		{name: "unknown subdomain", host: "www.example.com", path: "/hooks", rest: "/hooks"},
+		{name: "no bin", host: "localhost:9002", path: "/hooks", rest: "/hooks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req.Host = tt.host
+
+			b, rest, err := reg.Resolve(req)
+			if tt.wantErr {
+				require.ErrorIs(t, err, bin.ErrInvalidToken)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.rest, rest)
+			if tt.wantBin == "" {
+				assert.Nil(t, b)
+
+				return
+			}
+			require.NotNil(t, b)
+			assert.Equal(t, tt.wantBin, b.Token)
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, "/b/alice/hooks?a=1", bin.Path("alice", "/hooks?a=1"))
+	assert.Equal(t, "/hooks?a=1", bin.Path("", "/hooks?a=1"))
+}
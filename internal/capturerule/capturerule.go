@@ -0,0 +1,365 @@
+package capturerule
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rule actions.
+const (
+	ActionIgnore  = "ignore"  // matching requests are not captured
+	ActionInclude = "include" // matching requests are captured, subject to sampling
+)
+
+// skip reasons.
+const (
+	ReasonIgnored     = "ignored"      // matched an ignore rule
+	ReasonSampled     = "sampled"      // dropped by sampling of an include rule
+	ReasonNotIncluded = "not-included" // matched no rule while include rules exist
+)
+
+// sentinel errors.
+var (
+	ErrInvalidRule = errors.New("invalid capture rule")
+)
+
+// Header matches requests having header Name, if Value is set the header
+// value must contain it (case-insensitive).
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Rule selects requests by all of its set conditions, a rule without
+// conditions matches all requests. Include rules may sample matching
+// requests: Every keeps 1 in Every, PerMinute keeps the first PerMinute of
+// each minute; with both set, 1 in Every is kept up to PerMinute per minute.
+type Rule struct {
+	Action    string
+	Methods   []string
+	Path      string // glob
+	Headers   []Header
+	UserAgent string // substring, case-insensitive
+	CIDRs     []netip.Prefix
+	Every     int
+	PerMinute int
+}
+
+// Parse parses rules separated by ";", see ParseRule.
+func Parse(s string) ([]Rule, error) {
+	var rules []Rule
+	for text := range strings.SplitSeq(s, ";") {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		rule, err := ParseRule(text)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// ParseRule parses a rule: an action followed by space separated key=value
+// conditions, e.g. "include method=POST,PUT path=/hooks/* every=10" or
+// "ignore ua=kube-probe". Keys are method, path, header ("Name" or
+// "Name:value", repeatable), ua, cidr (comma separated), every and
+// per-minute.
+func ParseRule(s string) (Rule, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return Rule{}, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	rule := Rule{Action: fields[0]}
+	if rule.Action != ActionIgnore && rule.Action != ActionInclude {
+		return Rule{}, fmt.Errorf("%w %q: action must be %s or %s", ErrInvalidRule, s, ActionIgnore, ActionInclude)
+	}
+
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return Rule{}, fmt.Errorf("%w %q: expected key=value, got %q", ErrInvalidRule, s, field)
+		}
+
+		if err := rule.set(key, value); err != nil {
+			return Rule{}, fmt.Errorf("%w %q: %s: %w", ErrInvalidRule, s, key, err)
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return Rule{}, fmt.Errorf("%w %q: %w", ErrInvalidRule, s, err)
+	}
+
+	return rule, nil
+}
+
+func (r *Rule) set(key, value string) error {
+	switch key {
+	case "method":
+		for method := range strings.SplitSeq(value, ",") {
+			if method != "" {
+				r.Methods = append(r.Methods, strings.ToUpper(method))
+			}
+		}
+	case "path":
+		r.Path = value
+	case "header":
+		name, val, _ := strings.Cut(value, ":")
+		r.Headers = append(r.Headers, Header{Name: name, Value: val})
+	case "ua":
+		r.UserAgent = value
+	case "cidr":
+		for cidr := range strings.SplitSeq(value, ",") {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				return fmt.Errorf("invalid cidr: %w", err)
+			}
+			r.CIDRs = append(r.CIDRs, prefix.Masked())
+		}
+	case "every", "per-minute":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number: %w", err)
+		}
+		if key == "every" {
+			r.Every = n
+		} else {
+			r.PerMinute = n
+		}
+	default:
+		return errors.New("unknown key")
+	}
+
+	return nil
+}
+
+// validate checks r, rules built in code are validated by New.
+func (r Rule) validate() error {
+	if r.Action != ActionIgnore && r.Action != ActionInclude {
+		return fmt.Errorf("invalid action %q", r.Action)
+	}
+	if _, err := path.Match(r.Path, ""); err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	for _, h := range r.Headers {
+		if strings.TrimSpace(h.Name) == "" {
+			return errors.New("invalid header, empty name")
+		}
+	}
+	if r.Every < 0 || r.PerMinute < 0 {
+		return errors.New("invalid sampling, expected positive numbers")
+	}
+	if r.Action == ActionIgnore && (r.Every > 0 || r.PerMinute > 0) {
+		return errors.New("sampling is only supported by include rules")
+	}
+
+	return nil
+}
+
+// String returns the rule in ParseRule format.
+func (r Rule) String() string {
+	parts := []string{r.Action}
+	if len(r.Methods) > 0 {
+		parts = append(parts, "method="+strings.Join(r.Methods, ","))
+	}
+	if r.Path != "" {
+		parts = append(parts, "path="+r.Path)
+	}
+	for _, h := range r.Headers {
+		if h.Value != "" {
+			parts = append(parts, "header="+h.Name+":"+h.Value)
+		} else {
+			parts = append(parts, "header="+h.Name)
+		}
+	}
+	if r.UserAgent != "" {
+		parts = append(parts, "ua="+r.UserAgent)
+	}
+	if len(r.CIDRs) > 0 {
+		cidrs := make([]string, len(r.CIDRs))
+		for i, prefix := range r.CIDRs {
+			cidrs[i] = prefix.String()
+		}
+		parts = append(parts, "cidr="+strings.Join(cidrs, ","))
+	}
+	if r.Every > 0 {
+		parts = append(parts, "every="+strconv.Itoa(r.Every))
+	}
+	if r.PerMinute > 0 {
+		parts = append(parts, "per-minute="+strconv.Itoa(r.PerMinute))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Match reports whether req satisfies all conditions of r. CIDRs are matched
+// against the connection address, proxies are not trusted.
+func (r Rule) Match(req *http.Request) bool {
+	if len(r.Methods) > 0 && !containsFold(r.Methods, req.Method) {
+		return false
+	}
+	if r.Path != "" {
+		if ok, _ := path.Match(r.Path, req.URL.Path); !ok {
+			return false
+		}
+	}
+	for _, h := range r.Headers {
+		values, ok := req.Header[http.CanonicalHeaderKey(h.Name)]
+		if !ok || !strings.Contains(strings.ToLower(strings.Join(values, ",")), strings.ToLower(h.Value)) {
+			return false
+		}
+	}
+	if r.UserAgent != "" && !strings.Contains(strings.ToLower(req.UserAgent()), strings.ToLower(r.UserAgent)) {
+		return false
+	}
+	if len(r.CIDRs) > 0 && !r.matchAddr(req.RemoteAddr) {
+		return false
+	}
+
+	return true
+}
+
+func (r Rule) matchAddr(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range r.CIDRs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(values []string, s string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Decision is the result of Rules.Decide. Rule is the matching rule in
+// ParseRule format, empty if no rule matched.
+type Decision struct {
+	Capture bool
+	Rule    string
+	Reason  string // skip reason, empty if captured
+}
+
+// sampler keeps the sampling state of an include rule.
+type sampler struct {
+	mu     sync.Mutex
+	seen   int // matching requests sampled by Every
+	window time.Time
+	count  int // requests kept in window
+}
+
+// Rules decides which requests to capture, safe for concurrent use.
+type Rules struct {
+	rules      []Rule
+	texts      []string
+	samplers   []*sampler
+	hasInclude bool
+}
+
+// New creates a rule set, rules are evaluated in order and the first
+// matching rule decides. If there are include rules, requests matching no
+// rule are skipped.
+func New(rules ...Rule) (*Rules, error) {
+	rs := &Rules{
+		rules:    rules,
+		texts:    make([]string, len(rules)),
+		samplers: make([]*sampler, len(rules)),
+	}
+
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidRule, rule, err)
+		}
+
+		rs.texts[i] = rule.String()
+		rs.samplers[i] = &sampler{}
+		if rule.Action == ActionInclude {
+			rs.hasInclude = true
+		}
+	}
+
+	return rs, nil
+}
+
+// Decide decides whether to capture req received at now.
+func (rs *Rules) Decide(req *http.Request, now time.Time) Decision {
+	for i, rule := range rs.rules {
+		if !rule.Match(req) {
+			continue
+		}
+
+		decision := Decision{Rule: rs.texts[i], Capture: true}
+		switch {
+		case rule.Action == ActionIgnore:
+			decision.Capture = false
+			decision.Reason = ReasonIgnored
+		case !rs.samplers[i].keep(rule, now):
+			decision.Capture = false
+			decision.Reason = ReasonSampled
+		}
+
+		return decision
+	}
+
+	if rs.hasInclude {
+		return Decision{Reason: ReasonNotIncluded}
+	}
+
+	return Decision{Capture: true}
+}
+
+func (s *sampler) keep(rule Rule, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rule.PerMinute > 0 {
+		if window := now.Truncate(time.Minute); !window.Equal(s.window) {
+			s.window = window
+			s.count = 0
+		}
+		if s.count >= rule.PerMinute {
+			return false
+		}
+	}
+
+	s.seen++
+	if rule.Every > 1 && (s.seen-1)%rule.Every != 0 {
+		return false
+	}
+
+	if rule.PerMinute > 0 {
+		s.count++
+	}
+
+	return true
+}
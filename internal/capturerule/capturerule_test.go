@@ -0,0 +1,156 @@
+package capturerule_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/internal/capturerule"
+)
+
+func TestParse(t *testing.T) {
+	rules, err := capturerule.Parse(
+		"ignore ua=kube-probe; ; include method=post,PUT path=/hooks/* header=X-GitHub-Event:push " +
+			"header=X-Hub-Signature-256 cidr=10.0.0.1/8,::1/128 every=10 per-minute=5",
+	)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+
+	assert.Equal(t, capturerule.Rule{Action: capturerule.ActionIgnore, UserAgent: "kube-probe"}, rules[0])
+	assert.Equal(t, capturerule.Rule{
+		Action:  capturerule.ActionInclude,
+		Methods: []string{"POST", "PUT"},
+		Path:    "/hooks/*",
+		Headers: []capturerule.Header{
+			{Name: "X-GitHub-Event", Value: "push"},
+			{Name: "X-Hub-Signature-256"},
+		},
+		CIDRs:     []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")},
+		Every:     10,
+		PerMinute: 5,
+	}, rules[1])
+	assert.Equal(t, "include method=POST,PUT path=/hooks/* header=X-GitHub-Event:push header=X-Hub-Signature-256 "+
+		"cidr=10.0.0.0/8,::1/128 every=10 per-minute=5", rules[1].String())
+
+	for _, s := range []string{
+		"drop path=/x",
+		"ignore path",
+		"ignore path=[",
+		"ignore header=:x",
+		"ignore cidr=10.0.0.0",
+		"ignore every=x",
+		"ignore every=2",
+		"include per-minute=-1",
+		"include size=1",
+	} {
+		_, err = capturerule.Parse(s)
+		require.ErrorIs(t, err, capturerule.ErrInvalidRule, s)
+	}
+}
+
+func TestRule_Match(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/hooks/github?x=1", nil)
+	req.RemoteAddr = "10.1.2.3:5000"
+	req.Header.Set("User-Agent", "GitHub-Hookshot/abc")
+	req.Header.Set("X-GitHub-Event", "push")
+
+	for rule, want := range map[string]bool{
+		"include":                          true,
+		"include method=GET,post":          true,
+		"include method=GET":               false,
+		"include path=/hooks/*":            true,
+		"include path=/hooks":              false,
+		"include header=x-github-event":    true,
+		"include header=X-GitHub-Event:PU": true,
+		"include header=X-GitHub-Event:pr": false,
+		"include header=X-Missing":         false,
+		"include ua=hookshot":              true,
+		"include ua=kube-probe":            false,
+		"include cidr=10.0.0.0/8":          true,
+		"include cidr=192.168.0.0/16":      false,
+		"include method=POST ua=curl":      false,
+	} {
+		r, err := capturerule.ParseRule(rule)
+		require.NoError(t, err)
+		assert.Equal(t, want, r.Match(req), rule)
+	}
+
+	req.RemoteAddr = "[::ffff:10.1.2.3]:5000"
+	r, err := capturerule.ParseRule("ignore cidr=10.0.0.0/8")
+	require.NoError(t, err)
+	assert.True(t, r.Match(req))
+}
+
+func TestRules_Decide(t *testing.T) {
+	newRequest := func(method, target string) *http.Request {
+		return httptest.NewRequest(method, target, nil)
+	}
+	now := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+	t.Run("captures all without rules", func(t *testing.T) {
+		rules, err := capturerule.New()
+		require.NoError(t, err)
+
+		assert.Equal(t, capturerule.Decision{Capture: true}, rules.Decide(newRequest(http.MethodGet, "/"), now))
+	})
+
+	t.Run("first matching rule decides", func(t *testing.T) {
+		parsed, err := capturerule.Parse("ignore path=/ping; include method=POST; include path=/ping")
+		require.NoError(t, err)
+		rules, err := capturerule.New(parsed...)
+		require.NoError(t, err)
+
+		assert.Equal(t, capturerule.Decision{Rule: "ignore path=/ping", Reason: capturerule.ReasonIgnored},
+			rules.Decide(newRequest(http.MethodPost, "/ping"), now))
+		assert.Equal(t, capturerule.Decision{Rule: "include method=POST", Capture: true},
+			rules.Decide(newRequest(http.MethodPost, "/hook"), now))
+		assert.Equal(t, capturerule.Decision{Reason: capturerule.ReasonNotIncluded},
+			rules.Decide(newRequest(http.MethodGet, "/hook"), now))
+	})
+
+	t.Run("ignore rules keep other requests", func(t *testing.T) {
+		rules, err := capturerule.New(capturerule.Rule{Action: capturerule.ActionIgnore, Path: "/ping"})
+		require.NoError(t, err)
+
+		assert.True(t, rules.Decide(newRequest(http.MethodGet, "/hook"), now).Capture)
+	})
+
+	t.Run("samples 1 in n", func(t *testing.T) {
+		rules, err := capturerule.New(capturerule.Rule{Action: capturerule.ActionInclude, Every: 3})
+		require.NoError(t, err)
+
+		var kept []bool
+		for range 7 {
+			kept = append(kept, rules.Decide(newRequest(http.MethodGet, "/"), now).Capture)
+		}
+		assert.Equal(t, []bool{true, false, false, true, false, false, true}, kept)
+
+		decision := rules.Decide(newRequest(http.MethodGet, "/"), now)
+		assert.Equal(t, capturerule.ReasonSampled, decision.Reason)
+		assert.Equal(t, "include every=3", decision.Rule)
+	})
+
+	t.Run("keeps first n per minute", func(t *testing.T) {
+		rules, err := capturerule.New(capturerule.Rule{Action: capturerule.ActionInclude, PerMinute: 2})
+		require.NoError(t, err)
+
+		decide := func(at time.Time) bool { return rules.Decide(newRequest(http.MethodGet, "/"), at).Capture }
+
+		assert.True(t, decide(now))
+		assert.True(t, decide(now.Add(10*time.Second)))
+		assert.False(t, decide(now.Add(59*time.Second)))
+		assert.True(t, decide(now.Add(time.Minute)))
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		_, err := capturerule.New(capturerule.Rule{Action: capturerule.ActionIgnore, Path: "["})
+		require.ErrorIs(t, err, capturerule.ErrInvalidRule)
+
+		_, err = capturerule.New(capturerule.Rule{})
+		require.ErrorIs(t, err, capturerule.ErrInvalidRule)
+	})
+}
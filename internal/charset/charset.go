@@ -0,0 +1,111 @@
+package charset
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+const (
+	charsetUTF8    = "utf-8"
+	charsetUTF16LE = "utf-16le"
+	charsetUTF16BE = "utf-16be"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// Result holds the UTF-8 representation of a body and how it was obtained.
+type Result struct {
+	Text        string `json:"-"`
+	Charset     string `json:"charset,omitempty"`
+	BOM         bool   `json:"bom,omitempty"`
+	Transcoded  bool   `json:"transcoded,omitempty"`
+	InvalidUTF8 int    `json:"invalidUtf8,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Decode converts body to UTF-8 honouring byte order marks and the charset
+// parameter of given content type. Invalid UTF-8 sequences are counted and
+// replaced with U+FFFD in Text; the original bytes are never modified.
+func Decode(contentType string, body []byte) Result {
+	var result Result
+
+	switch {
+	case bytes.HasPrefix(body, bomUTF8):
+		result.Charset, result.BOM = charsetUTF8, true
+		body = body[len(bomUTF8):]
+	case bytes.HasPrefix(body, bomUTF16LE):
+		result.Charset, result.BOM = charsetUTF16LE, true
+		body = body[len(bomUTF16LE):]
+	case bytes.HasPrefix(body, bomUTF16BE):
+		result.Charset, result.BOM = charsetUTF16BE, true
+		body = body[len(bomUTF16BE):]
+	default:
+		if _, params, err := mime.ParseMediaType(contentType); err == nil {
+			result.Charset = strings.ToLower(strings.TrimSpace(params["charset"]))
+		}
+	}
+
+	if result.Charset == "" || result.Charset == charsetUTF8 || result.Charset == "us-ascii" {
+		result.Text, result.InvalidUTF8 = toValidUTF8(body)
+
+		return result
+	}
+
+	enc, err := htmlindex.Get(result.Charset)
+	if err != nil {
+		result.Error = "unknown charset: " + result.Charset
+		result.Text, result.InvalidUTF8 = toValidUTF8(body)
+
+		return result
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		result.Error = "decode error: " + err.Error()
+		result.Text, result.InvalidUTF8 = toValidUTF8(body)
+
+		return result
+	}
+
+	result.Text = string(decoded)
+	result.Transcoded = true
+
+	return result
+}
+
+// toValidUTF8 returns s with invalid UTF-8 sequences replaced by U+FFFD and
+// the number of invalid sequences found.
+func toValidUTF8(b []byte) (string, int) {
+	if utf8.Valid(b) {
+		return string(b), 0
+	}
+
+	var sb strings.Builder
+	invalid := 0
+	inInvalid := false
+
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size == 1 {
+			if !inInvalid {
+				invalid++
+				sb.WriteRune(utf8.RuneError)
+			}
+			inInvalid = true
+		} else {
+			sb.WriteRune(r)
+			inInvalid = false
+		}
+		b = b[size:]
+	}
+
+	return sb.String(), invalid
+}
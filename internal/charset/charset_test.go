@@ -0,0 +1,105 @@
+package charset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		text        string
+		charset     string
+		bom         bool
+		transcoded  bool
+		invalidUTF8 int
+		err         string
+	}{
+		{
+			name:        "plain utf-8 without charset",
+			contentType: "text/plain",
+			body:        []byte("merhaba dünya"),
+			text:        "merhaba dünya",
+		},
+		{
+			name:        "declared utf-8",
+			contentType: "text/plain; charset=UTF-8",
+			body:        []byte("şğü"),
+			text:        "şğü",
+			charset:     "utf-8",
+		},
+		{
+			name:        "iso-8859-9 is transcoded",
+			contentType: "text/plain; charset=iso-8859-9",
+			body:        []byte{0x49, 0xFE, 0xFD, 0xF0}, // Işığ
+			text:        "Işığ",
+			charset:     "iso-8859-9",
+			transcoded:  true,
+		},
+		{
+			name:        "windows-1254 is transcoded",
+			contentType: "application/x-www-form-urlencoded; charset=windows-1254",
+			body:        []byte{0x61, 0x64, 0x3D, 0xDE, 0x65, 0x6E, 0x6F, 0xF0, 0x6C, 0x75}, // ad=Şenoğlu
+			text:        "ad=Şenoğlu",
+			charset:     "windows-1254",
+			transcoded:  true,
+		},
+		{
+			name:        "utf-16le with bom",
+			contentType: "text/plain",
+			body:        []byte{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00},
+			text:        "hi",
+			charset:     "utf-16le",
+			bom:         true,
+			transcoded:  true,
+		},
+		{
+			name:        "utf-16be with bom overrides declared charset",
+			contentType: "text/plain; charset=iso-8859-1",
+			body:        []byte{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69},
+			text:        "hi",
+			charset:     "utf-16be",
+			bom:         true,
+			transcoded:  true,
+		},
+		{
+			name:        "utf-8 bom is stripped",
+			contentType: "application/json",
+			body:        []byte("\xEF\xBB\xBF{}"),
+			text:        "{}",
+			charset:     "utf-8",
+			bom:         true,
+		},
+		{
+			name:        "invalid utf-8 sequences are flagged",
+			contentType: "text/plain",
+			body:        []byte("ab\xFF\xFEcd\xC3"),
+			text:        "ab�cd�",
+			invalidUTF8: 2,
+		},
+		{
+			name:        "unknown charset",
+			contentType: "text/plain; charset=klingon",
+			body:        []byte("qapla"),
+			text:        "qapla",
+			charset:     "klingon",
+			err:         "unknown charset: klingon",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Decode(tt.contentType, tt.body)
+
+			assert.Equal(t, tt.text, result.Text)
+			assert.Equal(t, tt.charset, result.Charset)
+			assert.Equal(t, tt.bom, result.BOM)
+			assert.Equal(t, tt.transcoded, result.Transcoded)
+			assert.Equal(t, tt.invalidUTF8, result.InvalidUTF8)
+			assert.Equal(t, tt.err, result.Error)
+		})
+	}
+}
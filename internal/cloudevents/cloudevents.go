@@ -0,0 +1,298 @@
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// content modes.
+const (
+	ModeStructured = "structured"
+	ModeBinary     = "binary"
+	ModeBatch      = "batch"
+)
+
+const (
+	specVersion       = "1.0"
+	headerPrefix      = "Ce-"
+	contentTypeJSON   = "application/json"
+	contentTypeCEJSON = "application/cloudevents+json"
+	contentTypeBatch  = "application/cloudevents-batch+json"
+)
+
+// Event represents a normalised CloudEvent, regardless of its content mode.
+type Event struct {
+	SpecVersion     string            `json:"specversion"`
+	ID              string            `json:"id"`
+	Source          string            `json:"source"`
+	Type            string            `json:"type"`
+	Subject         string            `json:"subject,omitempty"`
+	Time            string            `json:"time,omitempty"`
+	DataContentType string            `json:"datacontenttype,omitempty"`
+	DataSchema      string            `json:"dataschema,omitempty"`
+	Extensions      map[string]string `json:"extensions,omitempty"`
+	Data            any               `json:"data,omitempty"`
+	Errors          []string          `json:"errors,omitempty"`
+}
+
+// Valid reports whether the event passed spec validation.
+func (e Event) Valid() bool {
+	return len(e.Errors) == 0
+}
+
+// Batch holds the events found in a single http request.
+type Batch struct {
+	Mode   string  `json:"mode"`
+	Events []Event `json:"events"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// Detect checks given header/body pair for CloudEvents in structured,
+// batched or binary content mode. Returns nil if request is not a CloudEvent.
+func Detect(header http.Header, body []byte) *Batch {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	switch {
+	case mediaType == contentTypeCEJSON:
+		return parseStructured(body)
+	case mediaType == contentTypeBatch:
+		return parseBatch(body)
+	case header.Get(headerPrefix+"Specversion") != "" || header.Get(headerPrefix+"Id") != "":
+		return parseBinary(header, body)
+	}
+
+	return nil
+}
+
+func parseStructured(body []byte) *Batch {
+	batch := &Batch{Mode: ModeStructured}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		batch.Error = "json.Unmarshal error: " + err.Error()
+
+		return batch
+	}
+
+	batch.Events = append(batch.Events, fromJSON(raw))
+
+	return batch
+}
+
+func parseBatch(body []byte) *Batch {
+	batch := &Batch{Mode: ModeBatch}
+
+	var raws []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raws); err != nil {
+		batch.Error = "json.Unmarshal error: " + err.Error()
+
+		return batch
+	}
+
+	batch.Events = make([]Event, 0, len(raws))
+	for _, raw := range raws {
+		batch.Events = append(batch.Events, fromJSON(raw))
+	}
+
+	return batch
+}
+
+func parseBinary(header http.Header, body []byte) *Batch {
+	event := Event{
+		DataContentType: header.Get("Content-Type"),
+		Extensions:      make(map[string]string),
+	}
+
+	for key, values := range header {
+		if !strings.HasPrefix(key, headerPrefix) {
+			continue
+		}
+
+		name := strings.ToLower(strings.TrimPrefix(key, headerPrefix))
+		value := strings.Join(values, ",")
+		// values are percent-encoded by the HTTP binding, invalid escapes
+		// are kept as is
+		if decoded, err := url.PathUnescape(value); err == nil {
+			value = decoded
+		}
+
+		if !event.setAttribute(name, value) {
+			event.Extensions[name] = value
+		}
+	}
+
+	if len(event.Extensions) == 0 {
+		event.Extensions = nil
+	}
+	if len(body) > 0 {
+		event.Data = decodeData(event.DataContentType, body)
+	}
+
+	event.validate()
+
+	return &Batch{Mode: ModeBinary, Events: []Event{event}}
+}
+
+func fromJSON(raw map[string]json.RawMessage) Event {
+	event := Event{Extensions: make(map[string]string)}
+
+	// datacontenttype drives how data is decoded, read it first.
+	if value, ok := raw["datacontenttype"]; ok {
+		_ = json.Unmarshal(value, &event.DataContentType)
+	}
+
+	for key, value := range raw {
+		switch key {
+		case "data":
+			if event.DataContentType == "" || isJSONContentType(event.DataContentType) {
+				var data any
+				if err := json.Unmarshal(value, &data); err == nil {
+					event.Data = data
+
+					continue
+				}
+			}
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				s = string(value)
+			}
+			event.Data = s
+		case "data_base64":
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				event.Errors = append(event.Errors, "data_base64 must be a string")
+
+				continue
+			}
+			decoded, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				event.Errors = append(event.Errors, "data_base64 is not valid base64")
+
+				continue
+			}
+			event.Data = decodeData(event.DataContentType, decoded)
+		default:
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				s = string(value)
+			}
+			if !event.setAttribute(key, s) {
+				event.Extensions[key] = s
+			}
+		}
+	}
+
+	if _, ok := raw["data"]; ok {
+		if _, ok = raw["data_base64"]; ok {
+			event.Errors = append(event.Errors, "data and data_base64 are mutually exclusive")
+		}
+	}
+
+	if len(event.Extensions) == 0 {
+		event.Extensions = nil
+	}
+
+	event.validate()
+
+	return event
+}
+
+func (e *Event) setAttribute(name, value string) bool {
+	switch name {
+	case "specversion":
+		e.SpecVersion = value
+	case "id":
+		e.ID = value
+	case "source":
+		e.Source = value
+	case "type":
+		e.Type = value
+	case "subject":
+		e.Subject = value
+	case "time":
+		e.Time = value
+	case "datacontenttype":
+		e.DataContentType = value
+	case "dataschema":
+		e.DataSchema = value
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (e *Event) validate() {
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"specversion", e.SpecVersion},
+		{"id", e.ID},
+		{"source", e.Source},
+		{"type", e.Type},
+	}
+
+	for _, attr := range required {
+		if attr.value == "" {
+			e.Errors = append(e.Errors, "missing required attribute: "+attr.name)
+		}
+	}
+
+	if e.SpecVersion != "" && e.SpecVersion != specVersion {
+		e.Errors = append(e.Errors, "unsupported specversion: "+e.SpecVersion)
+	}
+
+	if e.Time != "" {
+		if _, err := time.Parse(time.RFC3339Nano, e.Time); err != nil {
+			e.Errors = append(e.Errors, "time is not RFC 3339: "+e.Time)
+		}
+	}
+
+	for name := range e.Extensions {
+		if !isValidAttributeName(name) {
+			e.Errors = append(e.Errors, "invalid extension attribute name: "+name)
+		}
+	}
+	sort.Strings(e.Errors)
+}
+
+func isValidAttributeName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == contentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+func decodeData(contentType string, data []byte) any {
+	if contentType == "" || isJSONContentType(contentType) {
+		var decoded any
+		if err := json.Unmarshal(data, &decoded); err == nil {
+			return decoded
+		}
+	}
+
+	return string(data)
+}
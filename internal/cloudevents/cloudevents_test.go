@@ -0,0 +1,192 @@
+package cloudevents
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	t.Run("returns nil for plain requests", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+
+		assert.Nil(t, Detect(header, []byte(`{"foo": "bar"}`)))
+	})
+
+	t.Run("structured mode", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+		body := `{
+			"specversion": "1.0",
+			"id": "A234-1234-1234",
+			"source": "/mycontext",
+			"type": "com.example.someevent",
+			"subject": "larger-context",
+			"time": "2018-04-05T17:31:00Z",
+			"datacontenttype": "application/json",
+			"comexampleextension1": "value",
+			"data": {"appinfoA": "abc"}
+		}`
+
+		batch := Detect(header, []byte(body))
+		require.NotNil(t, batch)
+		assert.Equal(t, ModeStructured, batch.Mode)
+		require.Len(t, batch.Events, 1)
+
+		event := batch.Events[0]
+		assert.True(t, event.Valid(), event.Errors)
+		assert.Equal(t, "A234-1234-1234", event.ID)
+		assert.Equal(t, "/mycontext", event.Source)
+		assert.Equal(t, "com.example.someevent", event.Type)
+		assert.Equal(t, "larger-context", event.Subject)
+		assert.Equal(t, "2018-04-05T17:31:00Z", event.Time)
+		assert.Equal(t, "application/json", event.DataContentType)
+		assert.Equal(t, map[string]string{"comexampleextension1": "value"}, event.Extensions)
+		assert.Equal(t, map[string]any{"appinfoA": "abc"}, event.Data)
+	})
+
+	t.Run("structured mode with text data", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/cloudevents+json")
+		body := `{"specversion":"1.0","id":"1","source":"/s","type":"t",` +
+			`"datacontenttype":"text/plain","data":"{\"not\":\"decoded\"}"}`
+
+		batch := Detect(header, []byte(body))
+		require.NotNil(t, batch)
+		require.Len(t, batch.Events, 1)
+		assert.Equal(t, `{"not":"decoded"}`, batch.Events[0].Data)
+	})
+
+	t.Run("structured mode with data_base64", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/cloudevents+json")
+		body := `{"specversion":"1.0","id":"1","source":"/s","type":"t",` +
+			`"datacontenttype":"application/json","data_base64":"eyJmb28iOiJiYXIifQ=="}`
+
+		batch := Detect(header, []byte(body))
+		require.NotNil(t, batch)
+		require.Len(t, batch.Events, 1)
+		assert.True(t, batch.Events[0].Valid())
+		assert.Equal(t, map[string]any{"foo": "bar"}, batch.Events[0].Data)
+	})
+
+	t.Run("structured mode with invalid json", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/cloudevents+json")
+
+		batch := Detect(header, []byte(`{invalid`))
+		require.NotNil(t, batch)
+		assert.Contains(t, batch.Error, "json.Unmarshal error")
+		assert.Empty(t, batch.Events)
+	})
+
+	t.Run("structured mode validates required attributes", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/cloudevents+json")
+		body := `{"specversion":"0.3","source":"/s","time":"yesterday","Bad_Ext":"x"}`
+
+		batch := Detect(header, []byte(body))
+		require.NotNil(t, batch)
+		require.Len(t, batch.Events, 1)
+
+		event := batch.Events[0]
+		assert.False(t, event.Valid())
+		assert.Contains(t, event.Errors, "missing required attribute: id")
+		assert.Contains(t, event.Errors, "missing required attribute: type")
+		assert.Contains(t, event.Errors, "unsupported specversion: 0.3")
+		assert.Contains(t, event.Errors, "time is not RFC 3339: yesterday")
+		assert.Contains(t, event.Errors, "invalid extension attribute name: Bad_Ext")
+	})
+
+	t.Run("batch mode", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/cloudevents-batch+json")
+		body := `[
+			{"specversion":"1.0","id":"1","source":"/s","type":"t1","data":{"n":1}},
+			{"specversion":"1.0","id":"2","source":"/s","type":"t2"}
+		]`
+
+		batch := Detect(header, []byte(body))
+		require.NotNil(t, batch)
+		assert.Equal(t, ModeBatch, batch.Mode)
+		require.Len(t, batch.Events, 2)
+		assert.Equal(t, "t1", batch.Events[0].Type)
+		assert.Equal(t, map[string]any{"n": float64(1)}, batch.Events[0].Data)
+		assert.Equal(t, "t2", batch.Events[1].Type)
+		assert.Nil(t, batch.Events[1].Data)
+	})
+
+	t.Run("batch mode with invalid json", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/cloudevents-batch+json")
+
+		batch := Detect(header, []byte(`{"not":"an array"}`))
+		require.NotNil(t, batch)
+		assert.Contains(t, batch.Error, "json.Unmarshal error")
+	})
+
+	t.Run("binary mode", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+		header.Set("ce-specversion", "1.0")
+		header.Set("ce-id", "1234")
+		header.Set("ce-source", "https://example.com/orders")
+		header.Set("ce-type", "order.created")
+		header.Set("ce-subject", "order/42")
+		header.Set("ce-time", "2026-01-23T10:00:00.123Z")
+		header.Set("ce-traceparent", "00-abc-def-01")
+
+		batch := Detect(header, []byte(`{"orderId": 42}`))
+		require.NotNil(t, batch)
+		assert.Equal(t, ModeBinary, batch.Mode)
+		require.Len(t, batch.Events, 1)
+
+		event := batch.Events[0]
+		assert.True(t, event.Valid(), event.Errors)
+		assert.Equal(t, "1234", event.ID)
+		assert.Equal(t, "https://example.com/orders", event.Source)
+		assert.Equal(t, "order.created", event.Type)
+		assert.Equal(t, "order/42", event.Subject)
+		assert.Equal(t, "application/json", event.DataContentType)
+		assert.Equal(t, map[string]string{"traceparent": "00-abc-def-01"}, event.Extensions)
+		assert.Equal(t, map[string]any{"orderId": float64(42)}, event.Data)
+	})
+
+	t.Run("binary mode decodes percent-encoded values", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("ce-specversion", "1.0")
+		header.Set("ce-id", "1234")
+		header.Set("ce-source", "https://example.com/orders")
+		header.Set("ce-type", "order.created")
+		header.Set("ce-subject", "%E2%82%AC%20100%20%22net%22")
+		header.Set("ce-note", "50%+off%zz")
+
+		batch := Detect(header, nil)
+		require.NotNil(t, batch)
+		require.Len(t, batch.Events, 1)
+
+		event := batch.Events[0]
+		assert.Equal(t, `€ 100 "net"`, event.Subject)
+		assert.Equal(t, map[string]string{"note": "50%+off%zz"}, event.Extensions)
+	})
+
+	t.Run("binary mode with missing attributes", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "text/plain")
+		header.Set("ce-id", "1234")
+
+		batch := Detect(header, []byte("hello"))
+		require.NotNil(t, batch)
+		require.Len(t, batch.Events, 1)
+
+		event := batch.Events[0]
+		assert.False(t, event.Valid())
+		assert.Equal(t, "hello", event.Data)
+		assert.Contains(t, event.Errors, "missing required attribute: specversion")
+		assert.Contains(t, event.Errors, "missing required attribute: source")
+		assert.Contains(t, event.Errors, "missing required attribute: type")
+	})
+}
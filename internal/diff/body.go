@@ -0,0 +1,236 @@
+package diff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const (
+	jsonRoot        = "$"
+	maxLCSCells     = 1 << 20 // line diffs larger than this are not aligned
+	contentTypeForm = "application/x-www-form-urlencoded"
+)
+
+func compareBodies(left, right requeststore.Request, m matcher) Body {
+	if left.Body == "" && right.Body == "" {
+		return Body{Mode: ModeNone, Equal: true}
+	}
+	leftBody, rightBody := left.DecodedBody(), right.DecodedBody()
+
+	// previews of spooled bodies are compared as text, equality is decided
+	// by hashes of complete bodies.
+	if isTruncated(left) || isTruncated(right) {
+		return Body{
+			Mode:      ModeText,
+			Lines:     diffLines(leftBody, rightBody),
+			Equal:     bodyHash(left) == bodyHash(right),
+			Truncated: true,
+		}
+	}
+
+	leftJSON, leftOK := decodeJSON(leftBody)
+	rightJSON, rightOK := decodeJSON(rightBody)
+	if leftOK && rightOK {
+		changes := []Change{}
+		compareJSON(jsonRoot, "", leftJSON, rightJSON, m, &changes)
+
+		return Body{Mode: ModeJSON, Changes: changes, Equal: len(changes) == 0}
+	}
+
+	if isForm(left) || isForm(right) {
+		leftValues, _ := url.ParseQuery(leftBody)
+		rightValues, _ := url.ParseQuery(rightBody)
+		changes := compareValues(leftValues, rightValues, m.match)
+
+		return Body{Mode: ModeForm, Changes: changes, Equal: len(changes) == 0}
+	}
+
+	return Body{
+		Mode:  ModeText,
+		Lines: diffLines(leftBody, rightBody),
+		Equal: leftBody == rightBody,
+	}
+}
+
+// isTruncated reports whether req holds only a preview of its body.
+func isTruncated(req requeststore.Request) bool {
+	return req.BodyInfo != nil && req.BodyInfo.Size > int64(len(req.Body))
+}
+
+func bodyHash(req requeststore.Request) string {
+	if req.BodyInfo != nil && req.BodyInfo.SHA256 != "" {
+		return req.BodyInfo.SHA256
+	}
+	sum := sha256.Sum256([]byte(req.Body))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func isForm(req requeststore.Request) bool {
+	return strings.HasPrefix(strings.ToLower(req.Headers["Content-Type"]), contentTypeForm)
+}
+
+func decodeJSON(body string) (any, bool) {
+	if strings.TrimSpace(body) == "" {
+		return nil, false
+	}
+
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+
+	return v, true
+}
+
+func compareJSON(path, key string, left, right any, m matcher, changes *[]Change) {
+	if (key != "" && m.match(key)) || m.match(path) {
+		return
+	}
+
+	switch l := left.(type) {
+	case map[string]any:
+		r, ok := right.(map[string]any)
+		if !ok {
+			break
+		}
+
+		for _, k := range unionKeys(l, r) {
+			childPath := path + jsonKey(k)
+			lv, inLeft := l[k]
+			rv, inRight := r[k]
+
+			switch {
+			case !inLeft:
+				if !m.match(k) && !m.match(childPath) {
+					*changes = append(*changes, Change{Path: childPath, Kind: KindAdded, Right: rv})
+				}
+			case !inRight:
+				if !m.match(k) && !m.match(childPath) {
+					*changes = append(*changes, Change{Path: childPath, Kind: KindRemoved, Left: lv})
+				}
+			default:
+				compareJSON(childPath, k, lv, rv, m, changes)
+			}
+		}
+
+		return
+	case []any:
+		r, ok := right.([]any)
+		if !ok {
+			break
+		}
+
+		for i := range max(len(l), len(r)) {
+			childPath := path + "[" + strconv.Itoa(i) + "]"
+
+			switch {
+			case m.match(childPath):
+			case i >= len(l):
+				*changes = append(*changes, Change{Path: childPath, Kind: KindAdded, Right: r[i]})
+			case i >= len(r):
+				*changes = append(*changes, Change{Path: childPath, Kind: KindRemoved, Left: l[i]})
+			default:
+				compareJSON(childPath, "", l[i], r[i], m, changes)
+			}
+		}
+
+		return
+	}
+
+	if !reflect.DeepEqual(left, right) {
+		*changes = append(*changes, Change{Path: path, Kind: KindChanged, Left: left, Right: right})
+	}
+}
+
+// jsonKey returns path segment of given object key.
+func jsonKey(key string) string {
+	for i, r := range key {
+		isLetter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !isLetter && (i == 0 || r < '0' || r > '9') {
+			return "[" + strconv.Quote(key) + "]"
+		}
+	}
+	if key == "" {
+		return `[""]`
+	}
+
+	return "." + key
+}
+
+// diffLines returns a line diff of given texts based on their longest common
+// subsequence.
+func diffLines(left, right string) []Line {
+	a := splitLines(left)
+	b := splitLines(right)
+
+	if len(a)*len(b) > maxLCSCells {
+		lines := make([]Line, 0, len(a)+len(b))
+		for _, text := range a {
+			lines = append(lines, Line{Op: "-", Text: text})
+		}
+		for _, text := range b {
+			lines = append(lines, Line{Op: "+", Text: text})
+		}
+
+		return lines
+	}
+
+	// lcs[i][j] is the lcs length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]Line, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{Op: " ", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Op: "-", Text: a[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: "+", Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, Line{Op: "-", Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, Line{Op: "+", Text: b[j]})
+	}
+
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,236 @@
+package diff
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+// change kinds.
+const (
+	KindAdded   = "added"
+	KindRemoved = "removed"
+	KindChanged = "changed"
+)
+
+// body diff modes.
+const (
+	ModeNone = "none"
+	ModeJSON = "json"
+	ModeForm = "form"
+	ModeText = "text"
+)
+
+// Volatile lists header, query and body field names that usually differ
+// between two deliveries of the same webhook.
+var Volatile = []string{
+	"Date",
+	"X-Request-Id",
+	"X-Correlation-Id",
+	"X-Github-Delivery",
+	"X-Hub-Signature",
+	"X-Hub-Signature-256",
+	"X-Replayed-From",
+	"Stripe-Signature",
+	"Ce-Id",
+	"Ce-Time",
+	"id",
+	"uuid",
+	"nonce",
+	"signature",
+	"timestamp",
+	"time",
+	"date",
+	"created",
+	"created_at",
+	"createdAt",
+	"updated_at",
+	"updatedAt",
+}
+
+// Change is a single difference. Path is a header name, a query key, a form
+// key or a JSON path such as "$.items[0].name".
+type Change struct {
+	Path  string `json:"path"`
+	Kind  string `json:"kind"`
+	Left  any    `json:"left,omitempty"`
+	Right any    `json:"right,omitempty"`
+}
+
+// Line is a line of a text diff, Op is one of " ", "-" and "+".
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Body is the diff of request bodies. Changes are set for json and form
+// bodies, Lines for text bodies. Truncated is set if a body is spooled, only
+// its preview is diffed.
+type Body struct {
+	Mode      string   `json:"mode"`
+	Changes   []Change `json:"changes,omitempty"`
+	Lines     []Line   `json:"lines,omitempty"`
+	Equal     bool     `json:"equal"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// Result is the diff of two requests.
+type Result struct {
+	Left    string   `json:"left"`
+	Right   string   `json:"right"`
+	Request []Change `json:"request"` // method and path
+	Headers []Change `json:"headers"`
+	Query   []Change `json:"query"`
+	Body    Body     `json:"body"`
+	Ignored []string `json:"ignored,omitempty"`
+	Equal   bool     `json:"equal"`
+}
+
+// Options configures Compare. Ignore patterns are matched against header
+// names (case-insensitive), query and form keys, JSON field names and JSON
+// paths; "*" globs are supported, e.g. "$.data.*.ts".
+type Options struct {
+	Ignore []string
+}
+
+// Compare returns differences from left to right.
+func Compare(left, right requeststore.Request, opts Options) Result {
+	m := matcher(opts.Ignore)
+
+	leftURL, _ := url.Parse(left.URL)
+	rightURL, _ := url.Parse(right.URL)
+	if leftURL == nil {
+		leftURL = &url.URL{}
+	}
+	if rightURL == nil {
+		rightURL = &url.URL{}
+	}
+
+	result := Result{
+		Left:    left.ID,
+		Right:   right.ID,
+		Request: []Change{},
+		Ignored: opts.Ignore,
+	}
+
+	if left.Method != right.Method {
+		result.Request = append(result.Request, Change{
+			Path: "method", Kind: KindChanged, Left: left.Method, Right: right.Method,
+		})
+	}
+	if leftURL.Path != rightURL.Path {
+		result.Request = append(result.Request, Change{
+			Path: "path", Kind: KindChanged, Left: leftURL.Path, Right: rightURL.Path,
+		})
+	}
+
+	result.Headers = compareMaps(left.Headers, right.Headers, m.matchFold)
+	result.Query = compareValues(leftURL.Query(), rightURL.Query(), m.match)
+	result.Body = compareBodies(left, right, m)
+
+	result.Equal = len(result.Request) == 0 && len(result.Headers) == 0 && len(result.Query) == 0 && result.Body.Equal
+
+	return result
+}
+
+type matcher []string
+
+// match reports whether name (a key or a path) is ignored.
+func (m matcher) match(name string) bool {
+	for _, pattern := range m {
+		if pattern == name || glob(pattern, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// glob reports whether name matches pattern where "*" matches any sequence
+// of characters. Brackets are literal, unlike path.Match, to support JSON
+// paths.
+func glob(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return false
+	}
+
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(name, part)
+		if idx < 0 {
+			return false
+		}
+		name = name[idx+len(part):]
+	}
+
+	return strings.HasSuffix(name, parts[len(parts)-1])
+}
+
+func (m matcher) matchFold(name string) bool {
+	for _, pattern := range m {
+		if strings.EqualFold(pattern, name) {
+			return true
+		}
+	}
+
+	return m.match(name)
+}
+
+func compareMaps(left, right map[string]string, ignored func(string) bool) []Change {
+	changes := []Change{}
+
+	for _, key := range unionKeys(left, right) {
+		if ignored(key) {
+			continue
+		}
+
+		l, inLeft := left[key]
+		r, inRight := right[key]
+
+		switch {
+		case !inLeft:
+			changes = append(changes, Change{Path: key, Kind: KindAdded, Right: r})
+		case !inRight:
+			changes = append(changes, Change{Path: key, Kind: KindRemoved, Left: l})
+		case l != r:
+			changes = append(changes, Change{Path: key, Kind: KindChanged, Left: l, Right: r})
+		}
+	}
+
+	return changes
+}
+
+func compareValues(left, right url.Values, ignored func(string) bool) []Change {
+	return compareMaps(joinValues(left), joinValues(right), ignored)
+}
+
+func joinValues(values url.Values) map[string]string {
+	joined := make(map[string]string, len(values))
+	for key, vals := range values {
+		joined[key] = strings.Join(vals, ",")
+	}
+
+	return joined
+}
+
+func unionKeys[V any](left, right map[string]V) []string {
+	keys := make([]string, 0, len(left)+len(right))
+	for key := range left {
+		keys = append(keys, key)
+	}
+	for key := range right {
+		if _, ok := left[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
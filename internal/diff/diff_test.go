@@ -0,0 +1,176 @@
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+func TestCompare(t *testing.T) {
+	left := requeststore.Request{
+		ID:     "left",
+		Method: "POST",
+		URL:    "/hooks?event=push&delivery=1",
+		Headers: map[string]string{
+			"Content-Type":      "application/json",
+			"X-Github-Delivery": "aaa",
+			"X-Old":             "gone",
+			"User-Agent":        "GitHub-Hookshot/1",
+		},
+		Body: `{"action":"opened","id":1,"pr":{"title":"a","labels":["x","y"],"updated_at":"t1"},"n":1.0}`,
+	}
+	right := requeststore.Request{
+		ID:     "right",
+		Method: "POST",
+		URL:    "/hooks?event=pull_request&delivery=2",
+		Headers: map[string]string{
+			"Content-Type":      "application/json",
+			"X-Github-Delivery": "bbb",
+			"X-New":             "here",
+			"User-Agent":        "GitHub-Hookshot/2",
+		},
+		Body: `{"action":"closed","id":2,"pr":{"title":"a","labels":["x"],"updated_at":"t2","merged":true},"n":1.0}`,
+	}
+
+	t.Run("all differences", func(t *testing.T) {
+		result := Compare(left, right, Options{})
+
+		assert.False(t, result.Equal)
+		assert.Empty(t, result.Request)
+		assert.Equal(t, []Change{
+			{Path: "User-Agent", Kind: KindChanged, Left: "GitHub-Hookshot/1", Right: "GitHub-Hookshot/2"},
+			{Path: "X-Github-Delivery", Kind: KindChanged, Left: "aaa", Right: "bbb"},
+			{Path: "X-New", Kind: KindAdded, Right: "here"},
+			{Path: "X-Old", Kind: KindRemoved, Left: "gone"},
+		}, result.Headers)
+		assert.Equal(t, []Change{
+			{Path: "delivery", Kind: KindChanged, Left: "1", Right: "2"},
+			{Path: "event", Kind: KindChanged, Left: "push", Right: "pull_request"},
+		}, result.Query)
+
+		assert.Equal(t, ModeJSON, result.Body.Mode)
+		assert.Equal(t, []Change{
+			{Path: "$.action", Kind: KindChanged, Left: "opened", Right: "closed"},
+			{Path: "$.id", Kind: KindChanged, Left: json.Number("1"), Right: json.Number("2")},
+			{Path: "$.pr.labels[1]", Kind: KindRemoved, Left: "y"},
+			{Path: "$.pr.merged", Kind: KindAdded, Right: true},
+			{Path: "$.pr.updated_at", Kind: KindChanged, Left: "t1", Right: "t2"},
+		}, result.Body.Changes)
+	})
+
+	t.Run("ignore volatile and custom fields", func(t *testing.T) {
+		ignore := append([]string{"user-agent", "delivery", "$.pr.labels[*]", "action"}, Volatile...)
+		result := Compare(left, right, Options{Ignore: ignore})
+
+		assert.Equal(t, []Change{{Path: "X-New", Kind: KindAdded, Right: "here"}, {
+			Path: "X-Old", Kind: KindRemoved, Left: "gone",
+		}}, result.Headers)
+		assert.Equal(t, []Change{{Path: "event", Kind: KindChanged, Left: "push", Right: "pull_request"}}, result.Query)
+		assert.Equal(t, []Change{{Path: "$.pr.merged", Kind: KindAdded, Right: true}}, result.Body.Changes)
+	})
+
+	t.Run("equal requests", func(t *testing.T) {
+		result := Compare(left, left, Options{})
+
+		assert.True(t, result.Equal)
+		assert.True(t, result.Body.Equal)
+
+		data, err := json.Marshal(result)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"headers":[]`)
+	})
+
+	t.Run("method, path and type changes", func(t *testing.T) {
+		result := Compare(
+			requeststore.Request{Method: "GET", URL: "/a", Body: `{"v":{"a":1}}`},
+			requeststore.Request{Method: "PUT", URL: "/b", Body: `{"v":[1],"odd key":1}`},
+			Options{},
+		)
+
+		assert.Equal(t, []Change{
+			{Path: "method", Kind: KindChanged, Left: "GET", Right: "PUT"},
+			{Path: "path", Kind: KindChanged, Left: "/a", Right: "/b"},
+		}, result.Request)
+		assert.Equal(t, []Change{
+			{Path: `$["odd key"]`, Kind: KindAdded, Right: json.Number("1")},
+			{
+				Path:  "$.v",
+				Kind:  KindChanged,
+				Left:  map[string]any{"a": json.Number("1")},
+				Right: []any{json.Number("1")},
+			},
+		}, result.Body.Changes)
+	})
+}
+
+func TestCompare_formBody(t *testing.T) {
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	result := Compare(
+		requeststore.Request{Headers: headers, Body: "a=1&b=2&ts=100"},
+		requeststore.Request{Headers: headers, Body: "a=1&b=3&c=4&ts=200"},
+		Options{Ignore: []string{"ts"}},
+	)
+
+	assert.Equal(t, ModeForm, result.Body.Mode)
+	assert.Equal(t, []Change{
+		{Path: "b", Kind: KindChanged, Left: "2", Right: "3"},
+		{Path: "c", Kind: KindAdded, Right: "4"},
+	}, result.Body.Changes)
+}
+
+func TestCompare_textBody(t *testing.T) {
+	result := Compare(
+		requeststore.Request{Body: "one\ntwo\nthree"},
+		requeststore.Request{Body: "one\r\nTWO\r\nthree\r\nfour"},
+		Options{},
+	)
+
+	assert.Equal(t, ModeText, result.Body.Mode)
+	assert.False(t, result.Body.Equal)
+	assert.Equal(t, []Line{
+		{Op: " ", Text: "one"},
+		{Op: "-", Text: "two"},
+		{Op: "+", Text: "TWO"},
+		{Op: " ", Text: "three"},
+		{Op: "+", Text: "four"},
+	}, result.Body.Lines)
+
+	empty := Compare(requeststore.Request{}, requeststore.Request{}, Options{})
+	assert.Equal(t, ModeNone, empty.Body.Mode)
+	assert.True(t, empty.Equal)
+}
+
+func TestCompare_spooledBody(t *testing.T) {
+	left := requeststore.Request{
+		Body:     `{"big":`,
+		BodyInfo: &requeststore.BodyInfo{Size: 1 << 20, SHA256: "aaa", Spooled: true},
+	}
+	right := requeststore.Request{
+		Body:     `{"big":`,
+		BodyInfo: &requeststore.BodyInfo{Size: 1 << 20, SHA256: "bbb", Spooled: true},
+	}
+
+	result := Compare(left, right, Options{})
+
+	assert.Equal(t, ModeText, result.Body.Mode)
+	assert.True(t, result.Body.Truncated)
+	assert.False(t, result.Body.Equal)
+	assert.Equal(t, []Line{{Op: " ", Text: `{"big":`}}, result.Body.Lines)
+
+	right.BodyInfo.SHA256 = "aaa"
+	assert.True(t, Compare(left, right, Options{}).Equal)
+}
+
+func TestCompare_decodedBody(t *testing.T) {
+	result := Compare(
+		requeststore.Request{Body: "{\"ad\":\"\xDEeno\xF0lu\"}", BodyText: `{"ad":"Şenoğlu"}`},
+		requeststore.Request{Body: `{"ad":"Işık"}`},
+		Options{},
+	)
+
+	assert.Equal(t, ModeJSON, result.Body.Mode)
+	assert.Equal(t, []Change{{Path: "$.ad", Kind: KindChanged, Left: "Şenoğlu", Right: "Işık"}}, result.Body.Changes)
+}
@@ -25,6 +25,15 @@ func GetenvOrDefault[T any](name string, fallback T) T {
 			return fallback
 		}
 
+		if v, ok := any(parsed).(T); ok {
+			return v
+		}
+	case int64:
+		parsed, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return fallback
+		}
+
 		if v, ok := any(parsed).(T); ok {
 			return v
 		}
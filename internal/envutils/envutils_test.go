@@ -53,6 +53,17 @@ func TestGetenvOrDefault(t *testing.T) {
 		assert.Equal(t, false, val)
 	})
 
+	t.Run("Int64 retrieval", func(t *testing.T) {
+		val := envutils.GetenvOrDefault("TEST_INT_VAL", int64(1))
+		assert.Equal(t, int64(999), val)
+
+		val = envutils.GetenvOrDefault("TEST_STRING", int64(1))
+		assert.Equal(t, int64(1), val)
+
+		val = envutils.GetenvOrDefault("TEST_NON_EXISTENT", int64(1))
+		assert.Equal(t, int64(1), val)
+	})
+
 	t.Run("Fallback for non matching case", func(t *testing.T) {
 		val := envutils.GetenvOrDefault("TEST_INT_VAL", 0)
 		assert.Equal(t, 0, val)
@@ -0,0 +1,417 @@
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/release"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const (
+	// Version is the HAR spec version produced by Export.
+	Version = "1.2"
+
+	creatorName    = "basichttpdebugger"
+	encodingBase64 = "base64"
+	defProto       = "HTTP/1.1"
+	defScheme      = "http"
+)
+
+// sentinel errors.
+var (
+	ErrInvalidHAR    = errors.New("invalid har")
+	ErrMissingMethod = errors.New("missing request method")
+	ErrInvalidMethod = errors.New("invalid request method")
+)
+
+// tokenChars are the non-alphanumeric characters allowed in HTTP tokens.
+const tokenChars = "!#$%&'*+-.^_`|~"
+
+// HAR is the root object of an HTTP Archive.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log holds archive entries.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator describes the application that created the archive.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is a single request/response pair. Request notes are kept in
+// comment, pin and tags in custom _pinned and _tags fields.
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"`
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         Timings   `json:"timings"`
+	Comment         string    `json:"comment,omitempty"`
+	Pinned          bool      `json:"_pinned,omitempty"`
+	Tags            []string  `json:"_tags,omitempty"`
+}
+
+// Request is the request part of an entry.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// PostData holds request body. Non UTF-8 bodies are base64 encoded, marked
+// with the custom _encoding field. Truncated bodies are noted in comment.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"_encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Response is the response part of an entry.
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Content describes response body.
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Timings holds entry timings in milliseconds.
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// NameValue is a generic name/value pair used for headers, cookies and query
+// string parameters.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Export converts captured requests to a HAR archive. Entries carry the
+// response stored with the request, requests without a known response have
+// an empty response with status 0.
+func Export(requests []requeststore.Request) *HAR {
+	entries := make([]Entry, 0, len(requests))
+	for _, req := range requests {
+		entries = append(entries, exportEntry(req))
+	}
+
+	return &HAR{
+		Log: Log{
+			Version: Version,
+			Creator: Creator{Name: creatorName, Version: release.Version},
+			Entries: entries,
+		},
+	}
+}
+
+func exportEntry(req requeststore.Request) Entry {
+	proto := req.Proto
+	if proto == "" {
+		proto = defProto
+	}
+
+	u := &url.URL{Scheme: defScheme, Host: req.Host}
+	if parsed, err := url.Parse(bin.Path(req.Bin, req.URL)); err == nil {
+		u.Path, u.RawPath, u.RawQuery = parsed.Path, parsed.RawPath, parsed.RawQuery
+	}
+	if target, err := url.Parse(req.Target); err == nil && target.Scheme != "" {
+		u.Scheme = target.Scheme
+	}
+
+	headerKeys := make([]string, 0, len(req.Headers))
+	for key := range req.Headers {
+		headerKeys = append(headerKeys, key)
+	}
+	sort.Strings(headerKeys)
+
+	headers := make([]NameValue, 0, len(headerKeys))
+	for _, key := range headerKeys {
+		headers = append(headers, NameValue{Name: key, Value: req.Headers[key]})
+	}
+
+	cookies := []NameValue{}
+	if cookieHeader := req.Headers["Cookie"]; cookieHeader != "" {
+		parsed, _ := http.ParseCookie(cookieHeader)
+		for _, c := range parsed {
+			cookies = append(cookies, NameValue{Name: c.Name, Value: c.Value})
+		}
+	}
+
+	queryString := []NameValue{}
+	query := u.Query()
+	queryKeys := make([]string, 0, len(query))
+	for key := range query {
+		queryKeys = append(queryKeys, key)
+	}
+	sort.Strings(queryKeys)
+	for _, key := range queryKeys {
+		for _, value := range query[key] {
+			queryString = append(queryString, NameValue{Name: key, Value: value})
+		}
+	}
+
+	harReq := Request{
+		Method:      req.Method,
+		URL:         u.String(),
+		HTTPVersion: proto,
+		Cookies:     cookies,
+		Headers:     headers,
+		QueryString: queryString,
+		HeadersSize: -1,
+		BodySize:    len(req.Body),
+	}
+
+	if req.Body != "" {
+		postData := &PostData{MimeType: req.Headers["Content-Type"], Text: req.Body}
+		if !utf8.ValidString(req.Body) {
+			postData.Text = base64.StdEncoding.EncodeToString([]byte(req.Body))
+			postData.Encoding = encodingBase64
+		}
+		if req.BodyInfo != nil && req.BodyInfo.Size > int64(len(req.Body)) {
+			harReq.BodySize = int(req.BodyInfo.Size)
+			postData.Comment = fmt.Sprintf("body truncated to %d of %d bytes", len(req.Body), req.BodyInfo.Size)
+		}
+		harReq.PostData = postData
+	}
+
+	entry := Entry{
+		StartedDateTime: req.Time,
+		Request:         harReq,
+		Response: Response{
+			Cookies:     []NameValue{},
+			Headers:     []NameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Comment: req.Notes,
+		Pinned:  req.Pinned,
+		Tags:    req.Tags,
+	}
+	if req.Response != nil && req.Response.Error == "" {
+		entry.Response = exportResponse(req.Response)
+		entry.Time = req.Response.DurationMs
+		entry.Timings.Wait = req.Response.DurationMs
+	}
+
+	return entry
+}
+
+func exportResponse(resp *requeststore.Response) Response {
+	keys := make([]string, 0, len(resp.Headers))
+	for key := range resp.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	headers := make([]NameValue, 0, len(keys))
+	for _, key := range keys {
+		headers = append(headers, NameValue{Name: key, Value: resp.Headers[key]})
+	}
+
+	statusText := strings.TrimSpace(strings.TrimPrefix(resp.StatusText, strconv.Itoa(resp.Status)))
+
+	return Response{
+		Status:      resp.Status,
+		StatusText:  statusText,
+		HTTPVersion: resp.Proto,
+		Cookies:     []NameValue{},
+		Headers:     headers,
+		Content: Content{
+			Size:     int(resp.Size),
+			MimeType: resp.Headers["Content-Type"],
+			Text:     resp.Body,
+			Encoding: resp.Encoding,
+		},
+		HeadersSize: -1,
+		BodySize:    int(resp.Size),
+	}
+}
+
+// Import parses a HAR archive and returns its entries as requests with their
+// responses, entries with status 0 have no response. IDs are left empty, the
+// store assigns new ones.
+func Import(data []byte) ([]requeststore.Request, error) {
+	var archive HAR
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHAR, err)
+	}
+	if archive.Log.Version == "" {
+		return nil, fmt.Errorf("%w: missing log version", ErrInvalidHAR)
+	}
+
+	requests := make([]requeststore.Request, 0, len(archive.Log.Entries))
+	for i, entry := range archive.Log.Entries {
+		req, err := importEntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry %d: %w", ErrInvalidHAR, i, err)
+		}
+
+		requests = append(requests, req)
+	}
+
+	return requests, nil
+}
+
+func importEntry(entry Entry) (requeststore.Request, error) {
+	if entry.Request.Method == "" {
+		return requeststore.Request{}, ErrMissingMethod
+	}
+	if !validMethod(entry.Request.Method) {
+		return requeststore.Request{}, fmt.Errorf("%w %q", ErrInvalidMethod, entry.Request.Method)
+	}
+
+	u, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return requeststore.Request{}, fmt.Errorf("url parse error: %w", err)
+	}
+
+	headers := importHeaders(entry.Request.Headers)
+
+	host := u.Host
+	if hostHeader, ok := headers["Host"]; ok {
+		host = hostHeader
+		delete(headers, "Host")
+	}
+
+	var body string
+	if postData := entry.Request.PostData; postData != nil {
+		body = postData.Text
+		if postData.Encoding == encodingBase64 {
+			decoded, errDecode := base64.StdEncoding.DecodeString(postData.Text)
+			if errDecode != nil {
+				return requeststore.Request{}, fmt.Errorf("body decode error: %w", errDecode)
+			}
+			body = string(decoded)
+		}
+		if _, ok := headers["Content-Type"]; !ok && postData.MimeType != "" {
+			headers["Content-Type"] = postData.MimeType
+		}
+	}
+
+	return requeststore.Request{
+		Time:     entry.StartedDateTime,
+		Method:   strings.ToUpper(entry.Request.Method),
+		URL:      u.RequestURI(),
+		Headers:  headers,
+		Body:     body,
+		Host:     host,
+		Proto:    importProto(entry.Request.HTTPVersion),
+		Response: importResponse(entry),
+		Pinned:   entry.Pinned,
+		Tags:     requeststore.NormalizeTags(entry.Tags),
+		Notes:    entry.Comment,
+	}, nil
+}
+
+// importResponse returns the response of entry, nil if the entry has none.
+func importResponse(entry Entry) *requeststore.Response {
+	resp := entry.Response
+	if resp.Status == 0 {
+		return nil
+	}
+
+	duration := entry.Time
+	if duration <= 0 {
+		// unknown timings are -1
+		duration = max(entry.Timings.Send, 0) + max(entry.Timings.Wait, 0) + max(entry.Timings.Receive, 0)
+	}
+
+	size := int64(resp.Content.Size)
+	if size < 0 {
+		size = int64(len(resp.Content.Text))
+	}
+
+	return &requeststore.Response{
+		Status:     resp.Status,
+		StatusText: strings.TrimSpace(strconv.Itoa(resp.Status) + " " + resp.StatusText),
+		Proto:      importProto(resp.HTTPVersion),
+		Headers:    importHeaders(resp.Headers),
+		Body:       resp.Content.Text,
+		Encoding:   resp.Content.Encoding,
+		Size:       size,
+		DurationMs: duration,
+	}
+}
+
+// importHeaders returns headers with canonical keys, values of repeated
+// headers are joined with commas.
+func importHeaders(pairs []NameValue) map[string]string {
+	headers := make(map[string]string, len(pairs))
+	for _, h := range pairs {
+		// HTTP/2 pseudo headers (:authority, :path, ...) are not real headers
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+
+		key := http.CanonicalHeaderKey(h.Name)
+		if existing, ok := headers[key]; ok {
+			headers[key] = existing + "," + h.Value
+		} else {
+			headers[key] = h.Value
+		}
+	}
+
+	return headers
+}
+
+func importProto(version string) string {
+	proto := strings.ToUpper(version)
+	if !strings.HasPrefix(proto, "HTTP/") {
+		return defProto
+	}
+
+	return proto
+}
+
+// validMethod reports whether method is an HTTP token.
+func validMethod(method string) bool {
+	for _, r := range method {
+		isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlnum && !strings.ContainsRune(tokenChars, r) {
+			return false
+		}
+	}
+
+	return method != ""
+}
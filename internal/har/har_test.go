@@ -0,0 +1,227 @@
+package har
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+func TestExport(t *testing.T) {
+	started := time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC)
+
+	archive := Export([]requeststore.Request{
+		{
+			ID:     "1",
+			Time:   started,
+			Method: "POST",
+			URL:    "/webhook?b=2&a=1",
+			Host:   "example.com:9002",
+			Proto:  "HTTP/1.1",
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"Cookie":       "session=abc; theme=dark",
+			},
+			Body: `{"ok":true}`,
+		},
+	})
+
+	assert.Equal(t, Version, archive.Log.Version)
+	assert.Equal(t, creatorName, archive.Log.Creator.Name)
+	require.Len(t, archive.Log.Entries, 1)
+
+	entry := archive.Log.Entries[0]
+	assert.Equal(t, started, entry.StartedDateTime)
+	assert.Equal(t, "http://example.com:9002/webhook?b=2&a=1", entry.Request.URL)
+	assert.Equal(t, []NameValue{{"a", "1"}, {"b", "2"}}, entry.Request.QueryString)
+	assert.Equal(t, []NameValue{{"session", "abc"}, {"theme", "dark"}}, entry.Request.Cookies)
+	assert.Equal(t, []NameValue{
+		{"Content-Type", "application/json"},
+		{"Cookie", "session=abc; theme=dark"},
+	}, entry.Request.Headers)
+	require.NotNil(t, entry.Request.PostData)
+	assert.Equal(t, "application/json", entry.Request.PostData.MimeType)
+	assert.Equal(t, `{"ok":true}`, entry.Request.PostData.Text)
+	assert.Empty(t, entry.Request.PostData.Encoding)
+	assert.Equal(t, 0, entry.Response.Status)
+	assert.Empty(t, entry.Response.Content)
+
+	data, err := json.Marshal(archive)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"cookies":[]`)
+	assert.Contains(t, string(data), `"cache":{}`)
+}
+
+func TestExport_binRequest(t *testing.T) {
+	archive := Export([]requeststore.Request{
+		{ID: "1", Method: "GET", URL: "/hooks?a=1", Host: "example.com:9002", Bin: "alice"},
+	})
+
+	entry := archive.Log.Entries[0]
+	assert.Equal(t, "http://example.com:9002/b/alice/hooks?a=1", entry.Request.URL)
+	assert.Equal(t, []NameValue{{"a", "1"}}, entry.Request.QueryString)
+}
+
+func TestExport_sentResponse(t *testing.T) {
+	archive := Export([]requeststore.Request{
+		{
+			ID:     "1",
+			Method: "POST",
+			URL:    "/hooks",
+			Host:   "example.com:9002",
+			Response: &requeststore.Response{
+				Status:     202,
+				StatusText: "202 Accepted",
+				Proto:      "HTTP/1.1",
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       `{"ok":true}`,
+				Size:       11,
+			},
+		},
+	})
+
+	entry := archive.Log.Entries[0]
+	assert.Equal(t, 202, entry.Response.Status)
+	assert.Equal(t, "Accepted", entry.Response.StatusText)
+	assert.Equal(t, `{"ok":true}`, entry.Response.Content.Text)
+}
+
+func TestExport_outboundResponse(t *testing.T) {
+	archive := Export([]requeststore.Request{
+		{
+			ID:        "1",
+			Method:    "GET",
+			URL:       "/status",
+			Host:      "api.example.com",
+			Direction: requeststore.DirectionOutbound,
+			Target:    "https://api.example.com/status",
+			Response: &requeststore.Response{
+				Status:     201,
+				StatusText: "201 Created",
+				Proto:      "HTTP/1.1",
+				Headers:    map[string]string{"Content-Type": "text/plain", "X-Id": "7"},
+				Body:       "created",
+				Size:       7,
+				DurationMs: 12.5,
+			},
+		},
+	})
+
+	entry := archive.Log.Entries[0]
+	assert.Equal(t, "https://api.example.com/status", entry.Request.URL)
+	assert.Equal(t, 201, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Equal(t, []NameValue{{"Content-Type", "text/plain"}, {"X-Id", "7"}}, entry.Response.Headers)
+	assert.Equal(t, Content{Size: 7, MimeType: "text/plain", Text: "created"}, entry.Response.Content)
+	assert.InDelta(t, 12.5, entry.Time, 0.001)
+}
+
+func TestRoundTrip(t *testing.T) {
+	requests := []requeststore.Request{
+		{
+			Time:    time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC),
+			Method:  "POST",
+			URL:     "/upload?name=a%20b",
+			Host:    "localhost:9002",
+			Proto:   "HTTP/1.1",
+			Headers: map[string]string{"Content-Type": "application/octet-stream", "X-Trace": "1,2"},
+			Body:    string([]byte{0x00, 0xFF, 0xFE, 'a'}),
+		},
+		{
+			Time:    time.Date(2026, 10, 18, 12, 31, 0, 0, time.UTC),
+			Method:  "GET",
+			URL:     "/",
+			Host:    "localhost:9002",
+			Proto:   "HTTP/2.0",
+			Headers: map[string]string{"Accept": "*/*"},
+			Pinned:  true,
+			Tags:    []string{"deploy", "bug"},
+			Notes:   "broke staging\nsee logs",
+			Response: &requeststore.Response{
+				Status:     200,
+				StatusText: "200 OK",
+				Proto:      "HTTP/1.1",
+				Headers:    map[string]string{"Content-Type": "text/plain"},
+				Body:       "ok",
+				Size:       2,
+				DurationMs: 3.5,
+			},
+		},
+	}
+
+	data, err := json.Marshal(Export(requests))
+	require.NoError(t, err)
+
+	imported, err := Import(data)
+	require.NoError(t, err)
+	require.Len(t, imported, len(requests))
+
+	for i := range requests {
+		assert.Empty(t, imported[i].ID)
+		assert.True(t, requests[i].Time.Equal(imported[i].Time))
+		assert.Equal(t, requests[i].Method, imported[i].Method)
+		assert.Equal(t, requests[i].URL, imported[i].URL)
+		assert.Equal(t, requests[i].Host, imported[i].Host)
+		assert.Equal(t, requests[i].Proto, imported[i].Proto)
+		assert.Equal(t, requests[i].Headers, imported[i].Headers)
+		assert.Equal(t, requests[i].Body, imported[i].Body)
+		assert.Equal(t, requests[i].Pinned, imported[i].Pinned)
+		assert.Equal(t, requests[i].Tags, imported[i].Tags)
+		assert.Equal(t, requests[i].Notes, imported[i].Notes)
+		assert.Equal(t, requests[i].Response, imported[i].Response)
+	}
+}
+
+func TestImport(t *testing.T) {
+	t.Run("browser export with pseudo headers", func(t *testing.T) {
+		data := `{"log":{"version":"1.2","creator":{"name":"WebInspector","version":"537.36"},"entries":[{
+			"startedDateTime":"2026-10-18T10:00:00.000Z",
+			"request":{"method":"post","url":"https://api.example.com/v1/items?x=1","httpVersion":"http/2.0",
+				"headers":[{"name":":authority","value":"api.example.com"},{"name":"accept","value":"a"},
+					{"name":"accept","value":"b"}],
+				"postData":{"mimeType":"text/plain","text":"hello"}}}]}}`
+
+		imported, err := Import([]byte(data))
+		require.NoError(t, err)
+		require.Len(t, imported, 1)
+
+		req := imported[0]
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/v1/items?x=1", req.URL)
+		assert.Equal(t, "api.example.com", req.Host)
+		assert.Equal(t, "HTTP/2.0", req.Proto)
+		assert.Equal(t, map[string]string{"Accept": "a,b", "Content-Type": "text/plain"}, req.Headers)
+		assert.Equal(t, "hello", req.Body)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := Import([]byte("not json"))
+		assert.ErrorIs(t, err, ErrInvalidHAR)
+	})
+
+	t.Run("missing log", func(t *testing.T) {
+		_, err := Import([]byte(`{"foo":1}`))
+		assert.ErrorIs(t, err, ErrInvalidHAR)
+	})
+
+	t.Run("missing method", func(t *testing.T) {
+		_, err := Import([]byte(`{"log":{"version":"1.2","entries":[{"request":{"url":"http://x/"}}]}}`))
+		assert.ErrorIs(t, err, ErrInvalidHAR)
+		assert.ErrorIs(t, err, ErrMissingMethod)
+	})
+
+	t.Run("invalid method", func(t *testing.T) {
+		_, err := Import([]byte(`{"log":{"version":"1.2","entries":[{"request":{"method":"GET<img>","url":"http://x/"}}]}}`))
+		assert.ErrorIs(t, err, ErrInvalidHAR)
+		assert.ErrorIs(t, err, ErrInvalidMethod)
+	})
+
+	t.Run("invalid base64 body", func(t *testing.T) {
+		_, err := Import([]byte(`{"log":{"version":"1.2","entries":[{"request":{"method":"POST","url":"http://x/",
+			"postData":{"mimeType":"","text":"***","_encoding":"base64"}}}]}}`))
+		assert.ErrorIs(t, err, ErrInvalidHAR)
+	})
+}
@@ -0,0 +1,76 @@
+package httpserver
+
+import (
+	"encoding/json"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/vbyazilim/basichttpdebugger/internal/authorization"
+)
+
+// appendAuthorizationRows renders decoded Authorization header into the terminal table.
+func appendAuthorizationRows(
+	t table.Writer,
+	info *authorization.Info,
+	colorTitle, colorPayload, colorError text.Colors,
+) {
+	mergedRow := table.RowConfig{AutoMerge: true, AutoMergeAlign: text.AlignLeft}
+
+	t.AppendSeparator()
+	titleAuthorization := colorTitle.Sprint("Authorization")
+	t.AppendRow(table.Row{titleAuthorization, titleAuthorization}, mergedRow)
+	t.AppendSeparator()
+
+	t.AppendRow(table.Row{"Scheme", info.Scheme})
+	if info.Username != "" {
+		t.AppendRows([]table.Row{
+			{"Username", info.Username},
+			{"Password", info.Password},
+		})
+	}
+	if info.Error != "" {
+		txtError := colorError.Sprint(info.Error)
+		t.AppendRow(table.Row{txtError, txtError}, mergedRow)
+	}
+
+	if info.JWT == nil {
+		return
+	}
+
+	for _, section := range []struct {
+		title  string
+		values map[string]any
+	}{
+		{"JWT Header", info.JWT.Header},
+		{"JWT Claims", info.JWT.Claims},
+	} {
+		t.AppendSeparator()
+		titleSection := colorTitle.Sprint(section.title)
+		t.AppendRow(table.Row{titleSection, titleSection}, mergedRow)
+		t.AppendSeparator()
+
+		prettyJSON, err := json.MarshalIndent(section.values, "", "    ")
+		if err != nil {
+			txtError := colorError.Sprintf("json.MarshalIndent error: %s", err.Error())
+			t.AppendRow(table.Row{txtError, txtError}, mergedRow)
+
+			continue
+		}
+
+		payloadJSON := colorPayload.Sprintf("%s", prettyJSON)
+		t.AppendRow(table.Row{payloadJSON, payloadJSON}, mergedRow)
+	}
+
+	t.AppendSeparator()
+	for _, claim := range info.JWT.TimeClaims {
+		t.AppendRow(table.Row{claim.Name, claim.Human})
+	}
+
+	if info.JWT.Verified != nil {
+		t.AppendRow(table.Row{"Signature Verified By", info.JWT.VerifiedBy})
+		t.AppendRow(table.Row{"Is Valid?", *info.JWT.Verified})
+		if info.JWT.VerifyError != "" {
+			t.AppendRow(table.Row{"Verify Error", colorError.Sprint(info.JWT.VerifyError)})
+		}
+	}
+}
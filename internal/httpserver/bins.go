@@ -0,0 +1,123 @@
+package httpserver
+
+import (
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+// binHandlerFunc captures requests of bins into their own stores with their
+// own secrets and response, other requests, including "/b/" paths of unknown
+// bins, are handled with options.
+func binHandlerFunc(options *debugHandlerOptions, bins *bin.Registry) http.HandlerFunc {
+	defaultHandler := debugHandlerFunc(options)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, rest, err := bins.Resolve(r)
+		if err != nil || b == nil {
+			defaultHandler(w, r)
+
+			return
+		}
+
+		binOptions := *options
+		binOptions.store = b.Store
+		binOptions.bin = b.Token
+		binOptions.response = func() *bin.Response { return b.Response }
+		if b.SecretToken != "" || b.SecretTokenHeaderName != "" {
+			binOptions.secretToken = b.SecretToken
+			binOptions.secretTokenHeaderName = b.SecretTokenHeaderName
+		}
+		if b.HMACSecret != "" || b.HMACHeaderName != "" {
+			binOptions.hmacSecret = b.HMACSecret
+			binOptions.hmacHeaderName = b.HMACHeaderName
+		}
+
+		if rest != r.URL.Path {
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.URL = new(url.URL)
+			*r2.URL = *r.URL
+			r2.URL.Path = rest
+			r2.URL.RawPath = ""
+			if _, rawRest, ok := bin.Split(r.URL.RawPath); ok {
+				r2.URL.RawPath = rawRest
+			}
+			r2.RequestURI = r2.URL.RequestURI()
+			r = r2
+		}
+
+		debugHandlerFunc(&binOptions)(w, r)
+	}
+}
+
+// writeResponse writes resp, or the default "OK" text response if resp is
+// nil, and returns the writer for informational messages. Messages are
+// discarded for custom responses.
+func writeResponse(w http.ResponseWriter, resp *bin.Response) io.Writer {
+	status, header, body := responseParts(resp)
+	maps.Copy(w.Header(), header)
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, body)
+
+	if isDefaultResponse(resp) {
+		return w
+	}
+
+	return io.Discard
+}
+
+// sentResponse returns store representation of the response written by
+// writeResponse for resp, informational messages are not included.
+func sentResponse(resp *bin.Response, proto string) *requeststore.Response {
+	status, header, body := responseParts(resp)
+
+	headers := make(map[string]string, len(header))
+	for key, values := range header {
+		headers[key] = strings.Join(values, ",")
+	}
+
+	return &requeststore.Response{
+		Status:     status,
+		StatusText: strconv.Itoa(status) + " " + http.StatusText(status),
+		Proto:      proto,
+		Headers:    headers,
+		Body:       body,
+		Size:       int64(len(body)),
+	}
+}
+
+// responseParts returns status, headers and body of resp with defaults
+// applied.
+func responseParts(resp *bin.Response) (int, http.Header, string) {
+	header := http.Header{}
+	if isDefaultResponse(resp) {
+		header.Set(headerContentType, "text/plain")
+
+		return http.StatusOK, header, "OK\n"
+	}
+
+	for key, value := range resp.Headers {
+		header.Set(key, value)
+	}
+	if header.Get(headerContentType) == "" {
+		header.Set(headerContentType, "text/plain")
+	}
+
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return status, header, resp.Body
+}
+
+func isDefaultResponse(resp *bin.Response) bool {
+	return resp == nil || (resp.Status == 0 && len(resp.Headers) == 0 && resp.Body == "")
+}
@@ -0,0 +1,144 @@
+package httpserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+// requestBody holds an incoming request body. Small bodies are kept in memory,
+// larger ones are spooled to disk and only a preview is kept in memory.
+type requestBody struct {
+	data   []byte // whole body, or a preview if spooled to disk
+	path   string // spool file path, empty if body is in memory
+	size   int64
+	sha256 string
+}
+
+// readRequestBody reads r into memory up to maxMemory bytes, spills the rest
+// to a temporary file in spoolDir. maxMemory <= 0 disables spooling.
+func readRequestBody(r io.Reader, maxMemory int64, spoolDir string) (*requestBody, error) {
+	hasher := sha256.New()
+	tee := io.TeeReader(r, hasher)
+
+	var head []byte
+	var err error
+
+	if maxMemory <= 0 {
+		head, err = io.ReadAll(tee)
+	} else {
+		head, err = io.ReadAll(io.LimitReader(tee, maxMemory+1))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("body read error: %w", err)
+	}
+
+	body := &requestBody{data: head, size: int64(len(head))}
+
+	if maxMemory > 0 && body.size > maxMemory {
+		if err = body.spool(tee, spoolDir); err != nil {
+			return nil, err
+		}
+	}
+
+	body.sha256 = hex.EncodeToString(hasher.Sum(nil))
+
+	return body, nil
+}
+
+func (b *requestBody) spool(rest io.Reader, spoolDir string) error {
+	spoolFile, err := os.CreateTemp(filepath.Clean(spoolDir), "body-*.bin")
+	if err != nil {
+		return fmt.Errorf("spool create error: %w", err)
+	}
+	defer func() { _ = spoolFile.Close() }()
+
+	b.path = spoolFile.Name()
+
+	if _, err = spoolFile.Write(b.data); err != nil {
+		b.remove()
+
+		return fmt.Errorf("spool write error: %w", err)
+	}
+
+	n, err := io.Copy(spoolFile, rest)
+	if err != nil {
+		b.remove()
+
+		return fmt.Errorf("spool write error: %w", err)
+	}
+
+	b.size += n
+	if len(b.data) > maxSpooledBodyPreview {
+		b.data = bytes.Clone(b.data[:maxSpooledBodyPreview])
+	}
+
+	return nil
+}
+
+// spooled reports whether the body lives on disk.
+func (b *requestBody) spooled() bool {
+	return b.path != ""
+}
+
+// open returns a reader for the whole body.
+func (b *requestBody) open() (io.ReadCloser, error) {
+	if !b.spooled() {
+		return io.NopCloser(bytes.NewReader(b.data)), nil
+	}
+
+	f, err := os.Open(b.path)
+	if err != nil {
+		return nil, fmt.Errorf("spool open error: %w", err)
+	}
+
+	return f, nil
+}
+
+// copyTo writes the whole body to w.
+func (b *requestBody) copyTo(w io.Writer) error {
+	rc, err := b.open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+
+	if _, err = io.Copy(w, rc); err != nil {
+		return fmt.Errorf("body copy error: %w", err)
+	}
+
+	return nil
+}
+
+func (b *requestBody) remove() {
+	if b.path != "" {
+		_ = os.Remove(b.path)
+	}
+}
+
+// info returns store representation of the body.
+func (b *requestBody) info() *requeststore.BodyInfo {
+	if b == nil {
+		return nil
+	}
+
+	return &requeststore.BodyInfo{
+		Size:    b.size,
+		SHA256:  b.sha256,
+		Spooled: b.spooled(),
+		Path:    b.path,
+	}
+}
+
+// removeSpooledBody deletes spool file of given stored request, if any.
+func removeSpooledBody(req requeststore.Request) {
+	if req.BodyInfo != nil && req.BodyInfo.Path != "" {
+		_ = os.Remove(req.BodyInfo.Path)
+	}
+}
@@ -0,0 +1,27 @@
+package httpserver
+
+import (
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/vbyazilim/basichttpdebugger/internal/charset"
+)
+
+// appendCharsetRows renders body charset information into the terminal table.
+func appendCharsetRows(t table.Writer, result *charset.Result, colorError text.Colors) {
+	if result.Charset != "" {
+		charsetInfo := result.Charset
+		if result.BOM {
+			charsetInfo += " (BOM)"
+		}
+		if result.Transcoded {
+			charsetInfo += ", transcoded to utf-8"
+		}
+		t.AppendRow(table.Row{"Charset", charsetInfo})
+	}
+	if result.Error != "" {
+		t.AppendRow(table.Row{"Charset Error", colorError.Sprint(result.Error)})
+	}
+	if result.InvalidUTF8 > 0 {
+		t.AppendRow(table.Row{"Invalid UTF-8", colorError.Sprintf("%d invalid sequence(s) replaced", result.InvalidUTF8)})
+	}
+}
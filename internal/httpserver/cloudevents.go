@@ -0,0 +1,79 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/vbyazilim/basichttpdebugger/internal/cloudevents"
+)
+
+// appendCloudEventsRows renders detected CloudEvents into the terminal table.
+func appendCloudEventsRows(t table.Writer, batch *cloudevents.Batch, colorTitle, colorPayload, colorError text.Colors) {
+	mergedRow := table.RowConfig{AutoMerge: true, AutoMergeAlign: text.AlignLeft}
+
+	titleCloudEvents := colorTitle.Sprintf("CloudEvents (%s mode)", batch.Mode)
+	t.AppendRow(table.Row{titleCloudEvents, titleCloudEvents}, mergedRow)
+	t.AppendSeparator()
+
+	if batch.Error != "" {
+		txtError := colorError.Sprint(batch.Error)
+		t.AppendRow(table.Row{txtError, txtError}, mergedRow)
+		t.AppendSeparator()
+
+		return
+	}
+
+	for i, event := range batch.Events {
+		if len(batch.Events) > 1 {
+			titleEvent := colorTitle.Sprintf("Event %d of %d", i+1, len(batch.Events))
+			t.AppendRow(table.Row{titleEvent, titleEvent}, mergedRow)
+		}
+
+		t.AppendRows([]table.Row{
+			{"ID", event.ID},
+			{"Source", event.Source},
+			{"Type", event.Type},
+			{"Subject", event.Subject},
+			{"Time", event.Time},
+			{"Data Content Type", event.DataContentType},
+		})
+		if event.DataSchema != "" {
+			t.AppendRow(table.Row{"Data Schema", event.DataSchema})
+		}
+
+		extensionKeys := make([]string, 0, len(event.Extensions))
+		for key := range event.Extensions {
+			extensionKeys = append(extensionKeys, key)
+		}
+		sort.Strings(extensionKeys)
+
+		for _, key := range extensionKeys {
+			t.AppendRow(table.Row{"Extension: " + key, event.Extensions[key]})
+		}
+
+		t.AppendRow(table.Row{"Is Valid?", event.Valid()})
+		if !event.Valid() {
+			txtErrors := colorError.Sprint(strings.Join(event.Errors, "\n"))
+			t.AppendRow(table.Row{"Errors", txtErrors})
+		}
+
+		if event.Data != nil {
+			var data string
+			if s, ok := event.Data.(string); ok {
+				data = s
+			} else if pretty, err := json.MarshalIndent(event.Data, "", "    "); err == nil {
+				data = string(pretty)
+			} else {
+				data = fmt.Sprint(event.Data)
+			}
+
+			payloadData := colorPayload.Sprint(data)
+			t.AppendRow(table.Row{payloadData, payloadData}, mergedRow)
+		}
+		t.AppendSeparator()
+	}
+}
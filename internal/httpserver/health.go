@@ -0,0 +1,47 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/release"
+)
+
+// health endpoints of the debug server, they are not captured. Other methods
+// and paths below them are captured as usual.
+const (
+	HealthPath    = "/healthz"
+	ReadinessPath = "/readyz"
+	VersionPath   = "/version"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set(headerContentType, "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// healthHandler reports that the process is alive.
+func healthHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readinessHandler reports whether the server is serving, it fails while the
+// server is starting or shutting down.
+func (s *DebugServer) readinessHandler(w http.ResponseWriter, _ *http.Request) {
+	if !s.ready.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable"})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// versionHandler reports version and build information.
+func versionHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"version": release.Version,
+		"build":   release.BuildInformation,
+	})
+}
@@ -0,0 +1,46 @@
+package httpserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+	"github.com/vbyazilim/basichttpdebugger/internal/snippet"
+)
+
+const (
+	rawFileExt  = ".raw"
+	httpFileExt = ".http"
+)
+
+// httpFileName derives .http filename from raw request filename.
+func httpFileName(rawFilename string) string {
+	return strings.TrimSuffix(rawFilename, rawFileExt) + httpFileExt
+}
+
+// saveHTTPFile writes req as REST Client .http file. Bodies that can not be
+// written as text are saved next to it as "<name>.body" and referenced.
+func saveHTTPFile(filename string, req requeststore.Request, body *requestBody) error {
+	req.ID = strings.TrimSuffix(filepath.Base(filename), httpFileExt)
+
+	if body != nil && snippet.NeedsBodyFile(req) {
+		bodyFile, err := os.Create(filepath.Join(filepath.Dir(filename), req.ID+".body"))
+		if err != nil {
+			return fmt.Errorf("http file body create error: %w", err)
+		}
+		defer func() { _ = bodyFile.Close() }()
+
+		if err = body.copyTo(bodyFile); err != nil {
+			return err
+		}
+	}
+
+	content := snippet.HTTPFile([]requeststore.Request{req})
+	if err := os.WriteFile(filepath.Clean(filename), []byte(content), 0o600); err != nil {
+		return fmt.Errorf("http file write error: %w", err)
+	}
+
+	return nil
+}
@@ -1,7 +1,6 @@
 package httpserver
 
 import (
-	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
@@ -14,16 +13,24 @@ import (
 	"log"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/vbyazilim/basichttpdebugger/internal/authorization"
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/capturerule"
+	"github.com/vbyazilim/basichttpdebugger/internal/charset"
+	"github.com/vbyazilim/basichttpdebugger/internal/cloudevents"
+	"github.com/vbyazilim/basichttpdebugger/internal/metrics"
 	"github.com/vbyazilim/basichttpdebugger/internal/release"
 	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
 	"github.com/vbyazilim/basichttpdebugger/internal/stringutils"
@@ -37,6 +44,14 @@ var _ VerboseServer = (*DebugServer)(nil) // compile time proof
 // sentinel errors.
 var (
 	ErrValueRequired = errors.New("value required")
+	ErrInvalidValue  = errors.New("invalid value")
+)
+
+// file formats of saved requests.
+const (
+	SaveAsRaw  = "raw"
+	SaveAsHTTP = "http"
+	SaveAsBoth = "both"
 )
 
 const (
@@ -50,6 +65,11 @@ const (
 	headerContentType   = "Content-Type"
 	asciiSpaceThreshold = 32      // ASCII control characters below this are non-printable
 	maxImagePreviewSize = 5 << 20 // 5MB max for image preview in WebUI
+
+	defMaxBodyMemory      = 10 << 20 // 10MB, larger bodies are spooled to disk
+	maxSpooledBodyPreview = 16 << 10 // 16KB preview is kept in memory for spooled bodies
+
+	defUploadDirFormat = "%Y-%m-%d-%H%i%s-{hostname}-{url}-uploads"
 )
 
 // VerboseServer defines server behaviours.
@@ -63,18 +83,41 @@ type DebugServer struct {
 	HTTPServer                   *http.Server
 	OutputWriter                 io.WriteCloser
 	Store                        *requeststore.Store
+	Bins                         *bin.Registry
+	Metrics                      *metrics.Metrics
+	Dashboard                    http.Handler
+	DashboardPrefix              string
 	ListenAddr                   string
 	HMACSecret                   string
 	HMACHeaderName               string
 	RawHTTPRequestFileSaveFormat string
+	SaveAs                       string
 	SecretToken                  string
 	SecretTokenHeaderName        string
+	JWTSecret                    string
+	JWKSFile                     string
+	SpoolDir                     string
+	IgnoreUserAgents             []string
+	IgnorePaths                  []string
+	CaptureRules                 []capturerule.Rule
+	UploadDirFormat              string
+	MaxBodyMemory                int64
 	ReadTimeout                  time.Duration
 	ReadHeaderTimeout            time.Duration
 	WriteTimeout                 time.Duration
 	IdleTimeout                  time.Duration
 	Color                        bool
 	SaveRawHTTPRequest           bool
+	SaveUploads                  bool
+
+	response atomic.Pointer[bin.Response]
+	ready    atomic.Bool
+}
+
+// SetResponse sets the response of captured requests, nil restores the
+// default "OK" response. Bins keep their own responses.
+func (s *DebugServer) SetResponse(resp *bin.Response) {
+	s.response.Store(resp)
 }
 
 // Start starts http server.
@@ -93,7 +136,32 @@ func (s *DebugServer) Start() error {
 	if s.SaveRawHTTPRequest {
 		log.Println("saving raw http request is enabled")
 	}
-	if err := s.HTTPServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if s.SaveUploads {
+		log.Println("saving multipart uploads is enabled")
+	}
+	for _, rule := range s.captureRules() {
+		log.Printf("capture rule: %s\n", rule)
+	}
+	if s.Dashboard != nil {
+		log.Printf("web dashboard available at http://localhost%s%s/\n", s.ListenAddr, s.DashboardPrefix)
+	}
+
+	listener, err := net.Listen("tcp", s.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("server start error: %w", err)
+	}
+
+	return s.Serve(listener)
+}
+
+// Serve serves requests on listener, the server is ready until Stop is
+// called.
+func (s *DebugServer) Serve(listener net.Listener) error {
+	s.ready.Store(true)
+
+	if err := s.HTTPServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.ready.Store(false)
+
 		return fmt.Errorf("server start error: %w", err)
 	}
 
@@ -102,10 +170,25 @@ func (s *DebugServer) Start() error {
 
 // Stop stops/shutdowns server.
 func (s *DebugServer) Stop() error {
+	s.ready.Store(false)
+
 	if err := s.HTTPServer.Shutdown(context.Background()); err != nil {
 		return fmt.Errorf("server stop error: %w", err)
 	}
 
+	if s.Store != nil {
+		for _, req := range s.Store.GetAll() {
+			removeSpooledBody(req)
+		}
+	}
+	if s.Bins != nil {
+		for _, b := range s.Bins.List() {
+			for _, req := range b.Store.GetAll() {
+				removeSpooledBody(req)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -164,6 +247,21 @@ func WithOutputWriter(s string) Option {
 	}
 }
 
+// WithOutput sets output writer, where to write incoming webhook, e.g.
+// writerutils.NopCloser(io.Discard) to capture requests silently.
+func WithOutput(w io.WriteCloser) Option {
+	return func(d *DebugServer) {
+		d.OutputWriter = w
+	}
+}
+
+// WithResponse sets the response of captured requests, see SetResponse.
+func WithResponse(resp *bin.Response) Option {
+	return func(d *DebugServer) {
+		d.SetResponse(resp)
+	}
+}
+
 // WithHMACSecret sets HMAC secret value.
 func WithHMACSecret(s string) Option {
 	return func(d *DebugServer) {
@@ -194,6 +292,35 @@ func WithSecretTokenHeaderName(s string) Option {
 	}
 }
 
+// WithJWTSecret sets HMAC secret for verifying JWT signatures.
+func WithJWTSecret(s string) Option {
+	return func(d *DebugServer) {
+		d.JWTSecret = s
+	}
+}
+
+// WithJWKSFile sets JWKS file path for verifying JWT signatures.
+func WithJWKSFile(s string) Option {
+	return func(d *DebugServer) {
+		d.JWKSFile = s
+	}
+}
+
+// WithMaxBodyMemory sets max in-memory body size, larger bodies are spooled
+// to disk. Zero or negative value disables spooling.
+func WithMaxBodyMemory(n int64) Option {
+	return func(d *DebugServer) {
+		d.MaxBodyMemory = n
+	}
+}
+
+// WithSpoolDir sets directory for spooled request bodies.
+func WithSpoolDir(s string) Option {
+	return func(d *DebugServer) {
+		d.SpoolDir = s
+	}
+}
+
 // WithColor enables/disables colorful output.
 func WithColor(b bool) Option {
 	return func(d *DebugServer) {
@@ -215,6 +342,27 @@ func WithRawHTTPRequestFileSaveFormat(s string) Option {
 	}
 }
 
+// WithSaveUploads enables/disables saving multipart file uploads to disk.
+func WithSaveUploads(b bool) Option {
+	return func(d *DebugServer) {
+		d.SaveUploads = b
+	}
+}
+
+// WithUploadDirFormat sets directory name format for saved uploads of a request.
+func WithUploadDirFormat(s string) Option {
+	return func(d *DebugServer) {
+		d.UploadDirFormat = s
+	}
+}
+
+// WithSaveAs sets file format of saved requests: raw, http or both.
+func WithSaveAs(s string) Option {
+	return func(d *DebugServer) {
+		d.SaveAs = s
+	}
+}
+
 // WithStore sets the request store for web dashboard.
 func WithStore(s *requeststore.Store) Option {
 	return func(d *DebugServer) {
@@ -222,16 +370,73 @@ func WithStore(s *requeststore.Store) Option {
 	}
 }
 
+// WithBins sets the bin registry, enables capturing requests into isolated
+// bins.
+func WithBins(r *bin.Registry) Option {
+	return func(d *DebugServer) {
+		d.Bins = r
+	}
+}
+
+// WithIgnoreUserAgents ignores requests with user agents containing one of
+// given values (case-insensitive), e.g. "kube-probe".
+func WithIgnoreUserAgents(userAgents ...string) Option {
+	return func(d *DebugServer) {
+		d.IgnoreUserAgents = append(d.IgnoreUserAgents, userAgents...)
+	}
+}
+
+// WithIgnorePaths ignores requests to paths matching one of given globs,
+// e.g. "/health/*".
+func WithIgnorePaths(paths ...string) Option {
+	return func(d *DebugServer) {
+		d.IgnorePaths = append(d.IgnorePaths, paths...)
+	}
+}
+
+// WithCaptureRules sets rules deciding which requests are captured, see
+// capturerule.New. Ignored user agents and paths are checked first.
+func WithCaptureRules(rules ...capturerule.Rule) Option {
+	return func(d *DebugServer) {
+		d.CaptureRules = append(d.CaptureRules, rules...)
+	}
+}
+
+// WithMetrics records captured requests into m.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(d *DebugServer) {
+		d.Metrics = m
+	}
+}
+
+// WithDashboard serves handler under prefix (e.g. "/__debugger") on the
+// debug listener, requests under prefix are not captured.
+func WithDashboard(prefix string, handler http.Handler) Option {
+	return func(d *DebugServer) {
+		d.DashboardPrefix = prefix
+		d.Dashboard = handler
+	}
+}
+
 type debugHandlerOptions struct {
 	writer                       io.WriteCloser
 	store                        *requeststore.Store
+	metrics                      *metrics.Metrics
+	response                     func() *bin.Response
+	bin                          string
 	hmacSecret                   string
 	hmacHeaderName               string
 	secretToken                  string
 	secretTokenHeaderName        string
 	rawHTTPRequestFileSaveFormat string
-	color                        bool
+	saveAs                       string
+	jwtVerifier                  *authorization.Verifier
+	spoolDir                     string
+	uploadDirFormat              string
+	maxBodyMemory                int64
+	color                        bool // escape sequences are stripped from output if false
 	saveRawHTTPRequest           bool
+	saveUploads                  bool
 }
 
 func (debugHandlerOptions) getTerminalWidth() int {
@@ -252,24 +457,22 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 	colorError := text.Colors{text.BlinkSlow, text.FgRed}
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/plain")
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintln(w, "OK")
+		response := options.response()
+		out := writeResponse(w, response)
 
 		now := time.Now().UTC()
 
 		options.drawLine()
 
 		t := table.NewWriter()
-		t.SetOutputMirror(options.writer)
 		t.SetTitle(colorTitle.Sprint("Basic HTTP Debugger"))
 
 		filename := writerutils.GetFilePathName(options.writer)
 		if filename == "/dev/stdout" {
 			t.SetAllowedRowLength(options.getTerminalWidth())
-		} else {
-			fmt.Fprintln(w, "to see the result, run")
-			fmt.Fprintf(w, "tail -f %s\n", filename)
+		} else if filename != "" {
+			fmt.Fprintln(out, "to see the result, run")
+			fmt.Fprintf(out, "tail -f %s\n", filename)
 		}
 		t.SetColumnConfigs([]table.ColumnConfig{
 			{Number: 1, Colors: text.Colors{text.FgYellow}},
@@ -298,8 +501,18 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 			t.AppendRow(table.Row{key, strings.Join(r.Header[key], ",")})
 		}
 
+		authInfo := authorization.Parse(r.Header.Get("Authorization"), options.jwtVerifier, now)
+		if authInfo != nil {
+			appendAuthorizationRows(t, authInfo, colorTitle, colorPayload, colorError)
+		}
+
 		var bodyAsString string
+		var bodyText string
+		var bodyCharset *charset.Result
 		var storeFiles []requeststore.FileAttachment
+		var ceBatch *cloudevents.Batch
+		var reqBody *requestBody
+		var signature *requeststore.Signature
 
 		switch r.Method {
 		case http.MethodPost, http.MethodPut, http.MethodPatch:
@@ -311,7 +524,8 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 			})
 			t.AppendSeparator()
 
-			body, err := io.ReadAll(r.Body)
+			var err error
+			reqBody, err = readRequestBody(r.Body, options.maxBodyMemory, options.spoolDir)
 			if err != nil {
 				txtErrorRead := colorError.Sprintf("read error: %s", err.Error())
 				t.AppendRow(table.Row{txtErrorRead, txtErrorRead}, table.RowConfig{
@@ -324,6 +538,8 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 			}
 			defer func() { _ = r.Body.Close() }()
 
+			body := reqBody.data
+
 			if options.secretToken != "" {
 				t.AppendRow(table.Row{"Secret Token", options.secretToken})
 			}
@@ -332,8 +548,11 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 			}
 
 			if options.secretToken != "" && options.secretTokenHeaderName != "" {
+				matches := r.Header.Get(options.secretTokenHeaderName) == options.secretToken
+				signature = &requeststore.Signature{SecretToken: &matches}
+
 				t.AppendRows([]table.Row{
-					{"Secret Token Matches?", r.Header.Get(options.secretTokenHeaderName) == options.secretToken},
+					{"Secret Token Matches?", matches},
 				})
 				t.AppendSeparator()
 			}
@@ -345,30 +564,74 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 				t.AppendRow(table.Row{"HMAC Header Name", options.hmacHeaderName})
 			}
 			if options.hmacSecret != "" && options.hmacHeaderName != "" {
-				signature := r.Header.Get(options.hmacHeaderName)
+				incoming := r.Header.Get(options.hmacHeaderName)
 				h := hmac.New(sha256.New, []byte(options.hmacSecret))
-				_, _ = h.Write(body)
+				_ = reqBody.copyTo(h)
 
 				computedHash := hex.EncodeToString(h.Sum(nil))
-				cleanSignature := strings.TrimPrefix(signature, "sha256=")
+				cleanSignature := strings.TrimPrefix(incoming, "sha256=")
+				valid := hmac.Equal([]byte(computedHash), []byte(cleanSignature))
+				if signature == nil {
+					signature = &requeststore.Signature{}
+				}
+				signature.HMAC = &valid
 
 				t.AppendRows([]table.Row{
 					{"Incoming Signature", cleanSignature},
 					{"Expected Signature", computedHash},
-					{"Is Valid?", hmac.Equal([]byte(computedHash), []byte(cleanSignature))},
+					{"Is Valid?", valid},
 				})
 				t.AppendSeparator()
 			}
+
+			if !reqBody.spooled() {
+				ceBatch = cloudevents.Detect(r.Header, body)
+			}
+			if ceBatch != nil {
+				appendCloudEventsRows(t, ceBatch, colorTitle, colorPayload, colorError)
+			}
+
 			requestContentType := r.Header.Get("Content-Type")
 			t.AppendRow(table.Row{"Incoming", requestContentType})
-			t.AppendSeparator()
 
 			bodyAsString = string(body)
+			bodyText = bodyAsString
+
+			// multipart parts carry their own charset, display the rest as utf-8
+			if !strings.HasPrefix(requestContentType, "multipart/") {
+				decoded := charset.Decode(requestContentType, body)
+				if decoded.Charset != "" || decoded.Error != "" || isTextContentType(requestContentType) {
+					bodyText = decoded.Text
+				}
+				if decoded.Charset != "" || decoded.Error != "" ||
+					(decoded.InvalidUTF8 > 0 && isTextContentType(requestContentType)) {
+					bodyCharset = &decoded
+					appendCharsetRows(t, bodyCharset, colorError)
+				}
+			}
+			t.AppendSeparator()
 
 			switch {
-			case requestContentType == "application/json":
+			case reqBody.spooled() && !strings.HasPrefix(requestContentType, "multipart/form-data"):
+				t.AppendRows([]table.Row{
+					{"Body Size", formatFileSize(int(reqBody.size))},
+					{"Body SHA-256", reqBody.sha256},
+					{"Spooled To", reqBody.path},
+				})
+				t.AppendSeparator()
+
+				payloadPreview := colorPayload.Sprintf(
+					"%s\n[... preview of first %s]",
+					sanitizeBodyForDisplay(bodyText, requestContentType),
+					formatFileSize(len(body)),
+				)
+				t.AppendRow(table.Row{payloadPreview, payloadPreview}, table.RowConfig{
+					AutoMerge:      true,
+					AutoMergeAlign: text.AlignLeft,
+				})
+			case strings.HasPrefix(requestContentType, "application/json"):
 				var jsonBody map[string]any
-				if err = json.Unmarshal(body, &jsonBody); err != nil {
+				if err = json.Unmarshal([]byte(bodyText), &jsonBody); err != nil {
 					txtErrorUnmarshal := colorError.Sprintf("json.Unmarshal error: %s", err.Error())
 					t.AppendRow(table.Row{txtErrorUnmarshal, txtErrorUnmarshal}, table.RowConfig{
 						AutoMerge:      true,
@@ -398,7 +661,7 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 					AutoMergeAlign: text.AlignLeft,
 				})
 			case strings.HasPrefix(requestContentType, "application/x-www-form-urlencoded"):
-				formData, errForm := url.ParseQuery(bodyAsString)
+				formData, errForm := url.ParseQuery(bodyText)
 				if errForm != nil {
 					txtErrorForm := colorError.Sprintf("url.ParseQuery error: %s", errForm.Error())
 					t.AppendRow(table.Row{txtErrorForm, txtErrorForm}, table.RowConfig{
@@ -453,7 +716,20 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 					goto RENDER
 				}
 
-				reader := multipart.NewReader(bytes.NewReader(body), boundary)
+				multipartBody, errOpen := reqBody.open()
+				if errOpen != nil {
+					txtErrorOpen := colorError.Sprint(errOpen.Error())
+					t.AppendRow(table.Row{txtErrorOpen, txtErrorOpen}, table.RowConfig{
+						AutoMerge:      true,
+						AutoMergeAlign: text.AlignLeft,
+					})
+					t.AppendSeparator()
+
+					goto RENDER
+				}
+				defer func() { _ = multipartBody.Close() }()
+
+				reader := multipart.NewReader(multipartBody, boundary)
 
 				formFields := make(map[string][]string)
 				type fileInfo struct {
@@ -462,9 +738,14 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 					Size        int
 					ContentType string
 					Content     string
+					SHA256      string
+					Path        string
+					SaveError   string
 					RawData     []byte // for images
 				}
 				var files []fileInfo
+				var uploadDir string
+				var errUploadDir error
 
 				const maxContentDisplay = 1024 // 1KB
 
@@ -497,6 +778,34 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 							ContentType: part.Header.Get(headerContentType),
 						}
 
+						hasher := sha256.New()
+						var partDst io.Writer = hasher
+						var uploadFile *os.File
+
+						if options.saveUploads {
+							if uploadDir == "" && errUploadDir == nil {
+								uploadDir, errUploadDir = makeUploadDir(
+									stringutils.GetFormattedFilename(options.uploadDirFormat, r),
+								)
+							}
+
+							var errUpload error
+							if errUploadDir == nil {
+								uploadFile, errUpload = createUploadFile(uploadDir, fi.Filename)
+							} else {
+								errUpload = errUploadDir
+							}
+
+							if errUpload != nil {
+								fi.SaveError = errUpload.Error()
+							} else {
+								fi.Path = uploadFile.Name()
+								partDst = io.MultiWriter(hasher, uploadFile)
+							}
+						}
+
+						partReader := io.TeeReader(part, partDst)
+
 						isImage := isImageContentType(fi.ContentType)
 						isText := isTextContentType(fi.ContentType)
 
@@ -514,14 +823,25 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 						// Read only up to buffer limit
 						var previewData []byte
 						if bufferLimit > 0 {
-							limitReader := io.LimitReader(part, bufferLimit)
+							limitReader := io.LimitReader(partReader, bufferLimit)
 							previewData, _ = io.ReadAll(limitReader)
 						}
 
-						// Discard remaining bytes while counting total size
-						remainingBytes, _ := io.Copy(io.Discard, part)
+						// Discard (or save) remaining bytes while counting total size
+						remainingBytes, errCopy := io.Copy(io.Discard, partReader)
 						_ = part.Close()
 
+						if uploadFile != nil {
+							if errClose := uploadFile.Close(); errCopy == nil {
+								errCopy = errClose
+							}
+							if errCopy != nil {
+								fi.SaveError = "upload file write error: " + errCopy.Error()
+							}
+						}
+
+						fi.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+
 						fi.Size = len(previewData) + int(remainingBytes)
 
 						// Store preview data only if file is within limits
@@ -583,6 +903,15 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 								AutoMergeAlign: text.AlignLeft,
 							})
 						}
+
+						if options.saveUploads {
+							t.AppendRow(table.Row{"SHA-256", colorPayload.Sprint(fi.SHA256)})
+							if fi.SaveError != "" {
+								t.AppendRow(table.Row{"Saved To", colorError.Sprint(fi.SaveError)})
+							} else {
+								t.AppendRow(table.Row{"Saved To", colorPayload.Sprint(fi.Path)})
+							}
+						}
 					}
 				}
 
@@ -593,6 +922,9 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 						Filename:    fi.Filename,
 						ContentType: fi.ContentType,
 						Size:        fi.Size,
+						SHA256:      fi.SHA256,
+						Path:        fi.Path,
+						SaveError:   fi.SaveError,
 					}
 					if len(fi.RawData) > 0 {
 						sf.Data = base64.StdEncoding.EncodeToString(fi.RawData)
@@ -600,7 +932,7 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 					storeFiles = append(storeFiles, sf)
 				}
 			default:
-				payloadText := colorPayload.Sprintf("%s", body)
+				payloadText := colorPayload.Sprint(bodyText)
 				t.AppendSeparator()
 				t.AppendRow(
 					table.Row{payloadText, payloadText},
@@ -612,13 +944,17 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 			}
 		}
 	RENDER:
-		t.Render()
+		rendered := t.Render()
+		if !options.color {
+			rendered = text.StripEscape(rendered)
+		}
+		fmt.Fprintln(options.writer, rendered)
 
 		mwr := io.MultiWriter(options.writer)
 		var rawHRw *os.File
 		var rawErr error
 
-		if options.saveRawHTTPRequest {
+		if options.saveRawHTTPRequest && options.saveAs != SaveAsHTTP {
 			formattedFilename := stringutils.GetFormattedFilename(options.rawHTTPRequestFileSaveFormat, r)
 			rawHRw, rawErr = os.Create(filepath.Clean(formattedFilename))
 			if rawErr != nil {
@@ -628,7 +964,7 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 			}
 
 			mwr = io.MultiWriter(options.writer, rawHRw)
-			fmt.Fprintf(w, "Raw HTTP Request is saved to: %s\n", formattedFilename)
+			fmt.Fprintf(out, "Raw HTTP Request is saved to: %s\n", formattedFilename)
 		}
 
 	WRITERHR:
@@ -642,11 +978,16 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 		}
 		if bodyAsString != "" {
 			// Terminal gets sanitized body (no binary garbage)
-			sanitizedBody := sanitizeBodyForDisplay(bodyAsString, r.Header.Get(headerContentType))
+			sanitizedBody := sanitizeBodyForDisplay(bodyText, r.Header.Get(headerContentType))
 			fmt.Fprintf(options.writer, "\n%s\n", sanitizedBody)
-			// Raw file gets unsanitized body (for replay with nc)
+			if reqBody.spooled() {
+				fmt.Fprintf(options.writer, "[... %s total, spooled to %s]\n", formatFileSize(int(reqBody.size)), reqBody.path)
+			}
+			// Raw file gets unsanitized, complete body (for replay with nc)
 			if rawHRw != nil {
-				fmt.Fprintf(rawHRw, "\n%s\n", bodyAsString)
+				fmt.Fprint(rawHRw, "\n")
+				_ = reqBody.copyTo(rawHRw)
+				fmt.Fprint(rawHRw, "\n")
 			}
 		}
 		options.drawLine()
@@ -654,24 +995,51 @@ func debugHandlerFunc(options *debugHandlerOptions) http.HandlerFunc {
 			_ = rawHRw.Close()
 		}
 
-		if options.store == nil {
-			return
-		}
-
 		headers := make(map[string]string)
 		for _, key := range headerKeys {
 			headers[key] = strings.Join(r.Header[key], ",")
 		}
-		options.store.Add(requeststore.Request{
-			Time:    now,
-			Method:  r.Method,
-			URL:     r.URL.String(),
-			Headers: headers,
-			Body:    bodyAsString,
-			Host:    r.Host,
-			Proto:   r.Proto,
-			Files:   storeFiles,
-		})
+		captured := requeststore.Request{
+			Time:          now,
+			Method:        r.Method,
+			URL:           r.URL.String(),
+			Headers:       headers,
+			Body:          bodyAsString,
+			BodyText:      bodyTextForStore(bodyAsString, bodyText),
+			Charset:       bodyCharset,
+			Host:          r.Host,
+			Proto:         r.Proto,
+			Files:         storeFiles,
+			BodyInfo:      reqBody.info(),
+			CloudEvents:   ceBatch,
+			Authorization: authInfo,
+			Signature:     signature,
+			Bin:           options.bin,
+			Response:      sentResponse(response, r.Proto),
+		}
+
+		if options.saveRawHTTPRequest && options.saveAs != SaveAsRaw {
+			httpFilename := httpFileName(stringutils.GetFormattedFilename(options.rawHTTPRequestFileSaveFormat, r))
+			if err := saveHTTPFile(httpFilename, captured, reqBody); err != nil {
+				fmt.Println("err", err)
+			} else {
+				fmt.Fprintf(out, ".http file is saved to: %s\n", httpFilename)
+			}
+		}
+
+		if options.metrics != nil {
+			observeSignatures(options.metrics, signature, authInfo)
+		}
+
+		if options.store == nil {
+			if reqBody != nil {
+				reqBody.remove()
+			}
+
+			return
+		}
+
+		options.store.Add(captured)
 	}
 }
 
@@ -684,6 +1052,10 @@ func New(options ...Option) (*DebugServer, error) {
 		WriteTimeout:      defWriteTimeout,
 		IdleTimeout:       defIdleTimeout,
 		OutputWriter:      os.Stdout,
+		MaxBodyMemory:     defMaxBodyMemory,
+		SpoolDir:          os.TempDir(),
+		UploadDirFormat:   defUploadDirFormat,
+		SaveAs:            SaveAsRaw,
 	}
 
 	for _, opt := range options {
@@ -698,28 +1070,88 @@ func New(options ...Option) (*DebugServer, error) {
 		return nil, fmt.Errorf("invalid output: %w", ErrValueRequired)
 	}
 
-	targetFilename := writerutils.GetFilePathName(opts.OutputWriter)
-	if opts.Color && targetFilename == "/dev/stdout" {
+	switch opts.SaveAs {
+	case SaveAsRaw, SaveAsHTTP, SaveAsBoth:
+	default:
+		return nil, fmt.Errorf("invalid save as %q: %w", opts.SaveAs, ErrInvalidValue)
+	}
+
+	if opts.Dashboard != nil &&
+		(!strings.HasPrefix(opts.DashboardPrefix, "/") || strings.Trim(opts.DashboardPrefix, "/") == "" ||
+			strings.ContainsAny(opts.DashboardPrefix, "{} ")) {
+		return nil, fmt.Errorf("invalid dashboard prefix %q: %w", opts.DashboardPrefix, ErrInvalidValue)
+	}
+
+	jwtVerifier, err := authorization.NewVerifier(opts.JWTSecret, opts.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwt verifier: %w", err)
+	}
+
+	rules, err := capturerule.New(opts.captureRules()...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid capture rules: %w: %w", ErrInvalidValue, err)
+	}
+
+	if opts.MaxBodyMemory > 0 {
+		if err = os.MkdirAll(opts.SpoolDir, 0o750); err != nil {
+			return nil, fmt.Errorf("invalid spool dir: %w", err)
+		}
+	}
+	if opts.Store != nil {
+		opts.Store.OnEvict(removeSpooledBody)
+	}
+	if opts.Bins != nil {
+		opts.Bins.OnEvict(removeSpooledBody)
+	}
+
+	// colors are stripped per server, enabling them is process wide and left
+	// to Run
+	color := opts.Color && writerutils.GetFilePathName(opts.OutputWriter) == "/dev/stdout"
+	if color {
 		log.Println("color is enabled")
-		text.EnableColors()
-	} else {
-		text.DisableColors()
 	}
 
 	handlerOptions := debugHandlerOptions{
 		writer:                       opts.OutputWriter,
 		store:                        opts.Store,
+		metrics:                      opts.Metrics,
+		response:                     opts.response.Load,
 		hmacSecret:                   opts.HMACSecret,
 		hmacHeaderName:               opts.HMACHeaderName,
 		secretToken:                  opts.SecretToken,
 		secretTokenHeaderName:        opts.SecretTokenHeaderName,
-		color:                        opts.Color,
+		color:                        color,
 		rawHTTPRequestFileSaveFormat: opts.RawHTTPRequestFileSaveFormat,
 		saveRawHTTPRequest:           opts.SaveRawHTTPRequest,
+		saveAs:                       opts.SaveAs,
+		jwtVerifier:                  jwtVerifier,
+		spoolDir:                     opts.SpoolDir,
+		maxBodyMemory:                opts.MaxBodyMemory,
+		saveUploads:                  opts.SaveUploads,
+		uploadDirFormat:              opts.UploadDirFormat,
+	}
+
+	handler := debugHandlerFunc(&handlerOptions)
+	if opts.Bins != nil {
+		handler = binHandlerFunc(&handlerOptions, opts.Bins)
+	}
+
+	if opts.Metrics != nil {
+		handler = instrument(opts.Metrics, handler)
+	}
+
+	if len(opts.captureRules()) > 0 {
+		handler = opts.filter(rules, handler)
 	}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", debugHandlerFunc(&handlerOptions))
+	mux.HandleFunc("/", handler)
+	mux.HandleFunc("GET "+HealthPath, healthHandler)
+	mux.HandleFunc("GET "+ReadinessPath, opts.readinessHandler)
+	mux.HandleFunc("GET "+VersionPath, versionHandler)
+	if opts.Dashboard != nil {
+		mux.Handle(strings.TrimSuffix(opts.DashboardPrefix, "/")+"/", opts.Dashboard)
+	}
 
 	server := &http.Server{
 		Addr:              opts.ListenAddr,
@@ -735,6 +1167,16 @@ func New(options ...Option) (*DebugServer, error) {
 	return opts, nil
 }
 
+// bodyTextForStore returns transcoded body text only if it differs from the
+// original body, avoids storing the same payload twice.
+func bodyTextForStore(body, text string) string {
+	if body == text {
+		return ""
+	}
+
+	return text
+}
+
 // isImageContentType checks if the content type is an image.
 func isImageContentType(contentType string) bool {
 	return strings.HasPrefix(strings.ToLower(contentType), "image/")
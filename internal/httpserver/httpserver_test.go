@@ -4,20 +4,31 @@ import (
 	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/capturerule"
 	"github.com/vbyazilim/basichttpdebugger/internal/httpserver"
+	"github.com/vbyazilim/basichttpdebugger/internal/metrics"
+	"github.com/vbyazilim/basichttpdebugger/internal/release"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+	"github.com/vbyazilim/basichttpdebugger/internal/webui"
+	"github.com/vbyazilim/basichttpdebugger/internal/writerutils"
 )
 
 func TestNew(t *testing.T) {
@@ -339,6 +350,72 @@ func TestOutputWriter(t *testing.T) {
 		assert.NotEmpty(t, content)
 		assert.Contains(t, string(content), "Basic HTTP Debugger")
 	})
+
+	t.Run("Write to writer", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		server, err := httpserver.New(
+			httpserver.WithOutput(writerutils.NopCloser(&buf)),
+		)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		assert.Equal(t, "OK\n", rec.Body.String())
+		assert.Contains(t, buf.String(), "Basic HTTP Debugger")
+	})
+
+	t.Run("Strips colors without color", func(t *testing.T) {
+		text.EnableColors()
+		t.Cleanup(text.DisableColors)
+
+		var buf bytes.Buffer
+
+		server, err := httpserver.New(
+			httpserver.WithColor(true),
+			httpserver.WithOutput(writerutils.NopCloser(&buf)),
+		)
+		require.NoError(t, err)
+
+		server.HTTPServer.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		assert.Contains(t, buf.String(), "Basic HTTP Debugger")
+		assert.NotContains(t, buf.String(), "\x1b[")
+	})
+}
+
+func TestResponse(t *testing.T) {
+	store := requeststore.New(10)
+	server, err := httpserver.New(
+		httpserver.WithOutput(writerutils.NopCloser(io.Discard)),
+		httpserver.WithStore(store),
+		httpserver.WithResponse(&bin.Response{Status: http.StatusCreated, Body: "created"}),
+	)
+	require.NoError(t, err)
+
+	send := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(`{}`)))
+
+		return rec
+	}
+
+	rec := send()
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "created", rec.Body.String())
+
+	server.SetResponse(&bin.Response{Status: http.StatusTooManyRequests, Headers: map[string]string{"Retry-After": "1"}})
+	rec = send()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	server.SetResponse(nil)
+	rec = send()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "OK\n", rec.Body.String())
+
+	assert.Equal(t, 3, store.Count())
 }
 
 func TestInvalidJSONBody(t *testing.T) {
@@ -440,6 +517,74 @@ func TestSaveRawHTTPRequest(t *testing.T) {
 		}
 		assert.True(t, found, "expected .raw file to be created")
 	})
+
+	t.Run("Save as http file only", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(filepath.Join(tmpDir, "out.log")),
+			httpserver.WithSaveRawHTTPRequest(true),
+			httpserver.WithRawHTTPRequestFileSaveFormat(filepath.Join(tmpDir, "hook.raw")),
+			httpserver.WithSaveAs(httpserver.SaveAsHTTP),
+		)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/webhook?x=1", strings.NewReader(`{"test": "data"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		assert.Contains(t, rec.Body.String(), ".http file is saved to: "+filepath.Join(tmpDir, "hook.http"))
+		assert.NoFileExists(t, filepath.Join(tmpDir, "hook.raw"))
+
+		content, err := os.ReadFile(filepath.Join(tmpDir, "hook.http"))
+		require.NoError(t, err)
+		assert.Equal(t, `@host = example.com
+
+### POST /webhook?x=1
+POST http://{{host}}/webhook?x=1 HTTP/1.1
+Content-Type: application/json
+
+{"test": "data"}
+`, string(content))
+	})
+
+	t.Run("Save as both with binary body", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(filepath.Join(tmpDir, "out.log")),
+			httpserver.WithSaveRawHTTPRequest(true),
+			httpserver.WithRawHTTPRequestFileSaveFormat(filepath.Join(tmpDir, "blob.raw")),
+			httpserver.WithSaveAs(httpserver.SaveAsBoth),
+		)
+		require.NoError(t, err)
+
+		binaryBody := []byte{0x00, 0x01, 0xFF, 0xFE}
+		req := httptest.NewRequest(http.MethodPut, "/blob", bytes.NewReader(binaryBody))
+		req.Header.Set("Content-Type", "application/octet-stream")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		assert.FileExists(t, filepath.Join(tmpDir, "blob.raw"))
+
+		content, err := os.ReadFile(filepath.Join(tmpDir, "blob.http"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "< ./blob.body")
+
+		savedBody, err := os.ReadFile(filepath.Join(tmpDir, "blob.body"))
+		require.NoError(t, err)
+		assert.Equal(t, binaryBody, savedBody)
+	})
+
+	t.Run("Invalid save as value", func(t *testing.T) {
+		server, err := httpserver.New(httpserver.WithSaveAs("xml"))
+
+		require.ErrorIs(t, err, httpserver.ErrInvalidValue)
+		assert.Nil(t, server)
+	})
 }
 
 func TestVerboseServerInterface(t *testing.T) {
@@ -936,3 +1081,904 @@ func TestMultipartFormData(t *testing.T) {
 		assert.Contains(t, string(content), "[binary data:")
 	})
 }
+
+func TestCloudEvents(t *testing.T) {
+	t.Run("Binary mode CloudEvent", func(t *testing.T) {
+		tmpFile, err := os.CreateTemp("", "httpserver-cloudevents-*.log")
+		require.NoError(t, err)
+		defer os.Remove(tmpFile.Name())
+		tmpFile.Close()
+
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(tmpFile.Name()),
+			httpserver.WithStore(store),
+		)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(`{"orderId": 42}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Ce-Specversion", "1.0")
+		req.Header.Set("Ce-Id", "evt-1")
+		req.Header.Set("Ce-Source", "/orders")
+		req.Header.Set("Ce-Type", "order.created")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		server.OutputWriter.Close()
+
+		content, err := os.ReadFile(tmpFile.Name())
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "CloudEvents (binary mode)")
+		assert.Contains(t, string(content), "order.created")
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		require.NotNil(t, requests[0].CloudEvents)
+		require.Len(t, requests[0].CloudEvents.Events, 1)
+		assert.Equal(t, "evt-1", requests[0].CloudEvents.Events[0].ID)
+		assert.True(t, requests[0].CloudEvents.Events[0].Valid())
+	})
+
+	t.Run("Batched CloudEvents", func(t *testing.T) {
+		tmpFile, err := os.CreateTemp("", "httpserver-cloudevents-batch-*.log")
+		require.NoError(t, err)
+		defer os.Remove(tmpFile.Name())
+		tmpFile.Close()
+
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(tmpFile.Name()),
+			httpserver.WithStore(store),
+		)
+		require.NoError(t, err)
+
+		body := `[{"specversion":"1.0","id":"1","source":"/s","type":"a"},{"specversion":"1.0","id":"2","type":"b"}]`
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/cloudevents-batch+json")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		server.OutputWriter.Close()
+
+		content, err := os.ReadFile(tmpFile.Name())
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Event 2 of 2")
+		assert.Contains(t, string(content), "missing required attribute: source")
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		require.NotNil(t, requests[0].CloudEvents)
+		assert.Len(t, requests[0].CloudEvents.Events, 2)
+	})
+
+	t.Run("Plain JSON is not a CloudEvent", func(t *testing.T) {
+		store := requeststore.New(10)
+		server, err := httpserver.New(httpserver.WithStore(store))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"a": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		assert.Nil(t, requests[0].CloudEvents)
+	})
+}
+
+func TestAuthorizationDecoding(t *testing.T) {
+	t.Run("Basic credentials are decoded with masked password", func(t *testing.T) {
+		tmpFile, err := os.CreateTemp("", "httpserver-authorization-*.log")
+		require.NoError(t, err)
+		defer os.Remove(tmpFile.Name())
+		tmpFile.Close()
+
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(tmpFile.Name()),
+			httpserver.WithStore(store),
+		)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/private", nil)
+		req.SetBasicAuth("vigo", "top-secret")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		server.OutputWriter.Close()
+
+		content, err := os.ReadFile(tmpFile.Name())
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Username")
+		assert.Contains(t, string(content), "vigo")
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		require.NotNil(t, requests[0].Authorization)
+		assert.Equal(t, "vigo", requests[0].Authorization.Username)
+		assert.NotEqual(t, "top-secret", requests[0].Authorization.Password)
+	})
+
+	t.Run("JWT bearer token is verified with secret", func(t *testing.T) {
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithJWTSecret("jwt-secret"),
+			httpserver.WithStore(store),
+		)
+		require.NoError(t, err)
+
+		input := "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzdWIiOiI0MiJ9"
+		mac := hmac.New(sha256.New, []byte("jwt-secret"))
+		mac.Write([]byte(input))
+		token := input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+		req := httptest.NewRequest(http.MethodGet, "/private", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		require.NotNil(t, requests[0].Authorization)
+		require.NotNil(t, requests[0].Authorization.JWT)
+		assert.Equal(t, "42", requests[0].Authorization.JWT.Claims["sub"])
+		require.NotNil(t, requests[0].Authorization.JWT.Verified)
+		assert.True(t, *requests[0].Authorization.JWT.Verified)
+	})
+
+	t.Run("Invalid JWKS file", func(t *testing.T) {
+		server, err := httpserver.New(
+			httpserver.WithJWKSFile("/nonexistent/jwks.json"),
+		)
+		assert.Error(t, err)
+		assert.Nil(t, server)
+		assert.Contains(t, err.Error(), "invalid jwt verifier")
+	})
+}
+
+func TestCharsetDecoding(t *testing.T) {
+	t.Run("Turkish charset is transcoded for display only", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		outputFile := filepath.Join(tmpDir, "output.log")
+		rawFile := filepath.Join(tmpDir, "request.raw")
+
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(outputFile),
+			httpserver.WithStore(store),
+			httpserver.WithSaveRawHTTPRequest(true),
+			httpserver.WithRawHTTPRequestFileSaveFormat(rawFile),
+		)
+		require.NoError(t, err)
+
+		body := []byte{0x61, 0x64, 0x3D, 0xDE, 0x65, 0x6E, 0x6F, 0xF0, 0x6C, 0x75} // ad=Şenoğlu in windows-1254
+		req := httptest.NewRequest(http.MethodPost, "/bank", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=windows-1254")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		server.OutputWriter.Close()
+
+		content, err := os.ReadFile(outputFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "windows-1254, transcoded to utf-8")
+		assert.Contains(t, string(content), "Şenoğlu")
+
+		rawContent, err := os.ReadFile(rawFile)
+		require.NoError(t, err)
+		assert.True(t, bytes.Contains(rawContent, body), "raw file should keep original bytes")
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		assert.Equal(t, string(body), requests[0].Body)
+		assert.Equal(t, "ad=Şenoğlu", requests[0].BodyText)
+		require.NotNil(t, requests[0].Charset)
+		assert.True(t, requests[0].Charset.Transcoded)
+	})
+
+	t.Run("UTF-16 JSON with BOM is parsed", func(t *testing.T) {
+		tmpFile, err := os.CreateTemp("", "httpserver-charset-*.log")
+		require.NoError(t, err)
+		defer os.Remove(tmpFile.Name())
+		tmpFile.Close()
+
+		server, err := httpserver.New(httpserver.WithOutputWriter(tmpFile.Name()))
+		require.NoError(t, err)
+
+		body := []byte{0xFF, 0xFE}
+		for _, r := range `{"ad":"Işık"}` {
+			body = append(body, byte(r), byte(r>>8))
+		}
+		req := httptest.NewRequest(http.MethodPost, "/json", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		server.OutputWriter.Close()
+
+		content, err := os.ReadFile(tmpFile.Name())
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "utf-16le (BOM)")
+		assert.Contains(t, string(content), `"ad": "Işık"`)
+		assert.NotContains(t, string(content), "json.Unmarshal error")
+	})
+
+	t.Run("Plain UTF-8 body has no charset info", func(t *testing.T) {
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithOutput(writerutils.NopCloser(io.Discard)),
+			httpserver.WithStore(store),
+		)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/json", strings.NewReader(`{"ad":"Işık"}`))
+		req.Header.Set("Content-Type", "application/json")
+		server.HTTPServer.Handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		assert.Nil(t, requests[0].Charset)
+	})
+
+	t.Run("Invalid UTF-8 is flagged", func(t *testing.T) {
+		tmpFile, err := os.CreateTemp("", "httpserver-charset-invalid-*.log")
+		require.NoError(t, err)
+		defer os.Remove(tmpFile.Name())
+		tmpFile.Close()
+
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(tmpFile.Name()),
+			httpserver.WithStore(store),
+		)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/text", strings.NewReader("bad \xFF bytes"))
+		req.Header.Set("Content-Type", "text/plain")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		server.OutputWriter.Close()
+
+		content, err := os.ReadFile(tmpFile.Name())
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "1 invalid sequence(s) replaced")
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		require.NotNil(t, requests[0].Charset)
+		assert.Equal(t, 1, requests[0].Charset.InvalidUTF8)
+	})
+}
+
+func TestBodySpooling(t *testing.T) {
+	t.Run("Large body is spooled to disk", func(t *testing.T) {
+		spoolDir := t.TempDir()
+		outputFile := filepath.Join(t.TempDir(), "output.log")
+		rawFile := filepath.Join(t.TempDir(), "request.raw")
+
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(outputFile),
+			httpserver.WithStore(store),
+			httpserver.WithMaxBodyMemory(64),
+			httpserver.WithSpoolDir(spoolDir),
+			httpserver.WithHMACSecret("secret"),
+			httpserver.WithHMACHeaderName("X-Signature"),
+			httpserver.WithSaveRawHTTPRequest(true),
+			httpserver.WithRawHTTPRequestFileSaveFormat(rawFile),
+		)
+		require.NoError(t, err)
+
+		body := `{"data": "` + strings.Repeat("x", 100<<10) + `"}`
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		server.OutputWriter.Close()
+
+		content, err := os.ReadFile(outputFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Spooled To")
+		assert.Regexp(t, `Is Valid\?\s+\| true`, string(content))
+		assert.NotContains(t, string(content), "json.Unmarshal error")
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		require.NotNil(t, requests[0].BodyInfo)
+		assert.True(t, requests[0].BodyInfo.Spooled)
+		assert.Equal(t, int64(len(body)), requests[0].BodyInfo.Size)
+		assert.Less(t, len(requests[0].Body), len(body))
+
+		sum := sha256.Sum256([]byte(body))
+		assert.Equal(t, hex.EncodeToString(sum[:]), requests[0].BodyInfo.SHA256)
+
+		spooled, err := os.ReadFile(requests[0].BodyInfo.Path)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(spooled))
+
+		rawContent, err := os.ReadFile(rawFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(rawContent), body)
+	})
+
+	t.Run("Small body stays in memory", func(t *testing.T) {
+		spoolDir := t.TempDir()
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithStore(store),
+			httpserver.WithMaxBodyMemory(1024),
+			httpserver.WithSpoolDir(spoolDir),
+		)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/small", strings.NewReader("hello"))
+		req.Header.Set("Content-Type", "text/plain")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		requests := store.GetAll()
+		require.Len(t, requests, 1)
+		assert.Equal(t, "hello", requests[0].Body)
+		require.NotNil(t, requests[0].BodyInfo)
+		assert.False(t, requests[0].BodyInfo.Spooled)
+		assert.Equal(t, int64(5), requests[0].BodyInfo.Size)
+
+		entries, err := os.ReadDir(spoolDir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("Spooled multipart upload is parsed", func(t *testing.T) {
+		tmpFile, err := os.CreateTemp("", "httpserver-spool-multipart-*.log")
+		require.NoError(t, err)
+		defer os.Remove(tmpFile.Name())
+		tmpFile.Close()
+
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(tmpFile.Name()),
+			httpserver.WithMaxBodyMemory(128),
+			httpserver.WithSpoolDir(t.TempDir()),
+		)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		_ = writer.WriteField("title", "big upload")
+		part, _ := writer.CreateFormFile("archive", "archive.zip")
+		_, _ = part.Write(bytes.Repeat([]byte{0x00, 0x01}, 4096))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		server.OutputWriter.Close()
+
+		content, err := os.ReadFile(tmpFile.Name())
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "big upload")
+		assert.Contains(t, string(content), "archive.zip | 8.0 KB")
+	})
+
+	t.Run("Evicted spooled bodies are removed", func(t *testing.T) {
+		spoolDir := t.TempDir()
+		store := requeststore.New(1)
+		server, err := httpserver.New(
+			httpserver.WithStore(store),
+			httpserver.WithMaxBodyMemory(8),
+			httpserver.WithSpoolDir(spoolDir),
+		)
+		require.NoError(t, err)
+
+		for range 2 {
+			req := httptest.NewRequest(http.MethodPost, "/big", strings.NewReader(strings.Repeat("a", 64)))
+			rec := httptest.NewRecorder()
+			server.HTTPServer.Handler.ServeHTTP(rec, req)
+		}
+
+		entries, err := os.ReadDir(spoolDir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+}
+
+func TestSaveUploads(t *testing.T) {
+	newUploadRequest := func(t *testing.T) *http.Request {
+		t.Helper()
+
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		_ = writer.WriteField("title", "quarterly")
+		part, _ := writer.CreateFormFile("report", "report.pdf")
+		_, _ = part.Write([]byte("%PDF-1.4 fake pdf"))
+		part, _ = writer.CreateFormFile("report", "../report.pdf")
+		_, _ = part.Write([]byte("second"))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		return req
+	}
+
+	t.Run("Uploaded files are saved with checksums", func(t *testing.T) {
+		uploadRoot := t.TempDir()
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(filepath.Join(t.TempDir(), "out.log")),
+			httpserver.WithStore(store),
+			httpserver.WithSaveUploads(true),
+			httpserver.WithUploadDirFormat(filepath.Join(uploadRoot, "req-{url}")),
+		)
+		require.NoError(t, err)
+
+		for range 2 {
+			rec := httptest.NewRecorder()
+			server.HTTPServer.Handler.ServeHTTP(rec, newUploadRequest(t))
+		}
+
+		entries, err := os.ReadDir(uploadRoot)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "req-_upload", entries[0].Name())
+		assert.Equal(t, "req-_upload-2", entries[1].Name())
+
+		requests := store.GetAll()
+		require.Len(t, requests, 2)
+
+		files := requests[0].Files
+		require.Len(t, files, 2)
+
+		sum := sha256.Sum256([]byte("%PDF-1.4 fake pdf"))
+		assert.Equal(t, hex.EncodeToString(sum[:]), files[0].SHA256)
+		assert.Equal(t, "report.pdf", filepath.Base(files[0].Path))
+		assert.Equal(t, "report-2.pdf", filepath.Base(files[1].Path))
+		assert.Empty(t, files[0].SaveError)
+
+		saved, err := os.ReadFile(files[0].Path)
+		require.NoError(t, err)
+		assert.Equal(t, "%PDF-1.4 fake pdf", string(saved))
+
+		saved, err = os.ReadFile(files[1].Path)
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(saved))
+	})
+
+	t.Run("Saved paths are shown in output", func(t *testing.T) {
+		tmpFile, err := os.CreateTemp("", "httpserver-uploads-*.log")
+		require.NoError(t, err)
+		defer os.Remove(tmpFile.Name())
+		tmpFile.Close()
+
+		uploadRoot := t.TempDir()
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(tmpFile.Name()),
+			httpserver.WithSaveUploads(true),
+			httpserver.WithUploadDirFormat(filepath.Join(uploadRoot, "uploads")),
+		)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, newUploadRequest(t))
+		server.OutputWriter.Close()
+
+		content, err := os.ReadFile(tmpFile.Name())
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "SHA-256")
+		assert.Contains(t, string(content), "Saved To")
+		assert.Contains(t, string(content), filepath.Join(uploadRoot, "uploads", "report.pdf"))
+	})
+
+	t.Run("Uploads are not saved by default", func(t *testing.T) {
+		store := requeststore.New(10)
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(filepath.Join(t.TempDir(), "out.log")),
+			httpserver.WithStore(store),
+		)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, newUploadRequest(t))
+
+		files := store.GetAll()[0].Files
+		require.Len(t, files, 2)
+		assert.Empty(t, files[0].Path)
+		assert.NotEmpty(t, files[0].SHA256)
+	})
+}
+
+func TestBins(t *testing.T) {
+	newServer := func(t *testing.T) (*httpserver.DebugServer, *requeststore.Store, *bin.Registry) {
+		t.Helper()
+
+		tmpFile, err := os.CreateTemp("", "httpserver-bins-*.log")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = os.Remove(tmpFile.Name()) })
+		tmpFile.Close()
+
+		store := requeststore.New(10)
+		bins := bin.NewRegistry(10, 10)
+		server, err := httpserver.New(
+			httpserver.WithOutputWriter(tmpFile.Name()),
+			httpserver.WithStore(store),
+			httpserver.WithBins(bins),
+			httpserver.WithSecretToken("server-token"),
+			httpserver.WithSecretTokenHeaderName("X-Token"),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = server.OutputWriter.Close() })
+
+		return server, store, bins
+	}
+
+	t.Run("captures into bin store with own secret and response", func(t *testing.T) {
+		server, store, bins := newServer(t)
+		b, err := bins.Create(bin.Config{
+			Token:                 "alice",
+			SecretToken:           "alice-token",
+			SecretTokenHeaderName: "X-Token",
+			Response: &bin.Response{
+				Status:  http.StatusAccepted,
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    `{"ok":true}`,
+			},
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/b/alice/hooks/stripe?x=1", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Token", "alice-token")
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"ok":true}`, rec.Body.String())
+
+		assert.Equal(t, 0, store.Count())
+		captured := b.Store.GetAll()
+		require.Len(t, captured, 1)
+		assert.Equal(t, "/hooks/stripe?x=1", captured[0].URL)
+		assert.Equal(t, "alice", captured[0].Bin)
+		require.NotNil(t, captured[0].Signature)
+		assert.True(t, *captured[0].Signature.SecretToken)
+		require.NotNil(t, captured[0].Response)
+		assert.Equal(t, http.StatusAccepted, captured[0].Response.Status)
+		assert.Equal(t, "application/json", captured[0].Response.Headers["Content-Type"])
+		assert.JSONEq(t, `{"ok":true}`, captured[0].Response.Body)
+	})
+
+	t.Run("captures subdomain requests", func(t *testing.T) {
+		server, store, bins := newServer(t)
+		b, err := bins.Create(bin.Config{Token: "bob"})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		req.Host = "bob.hooks.example.com"
+		rec := httptest.NewRecorder()
+
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, strings.HasPrefix(rec.Body.String(), "OK\n"))
+		assert.Equal(t, 0, store.Count())
+		require.Equal(t, 1, b.Store.Count())
+		assert.Equal(t, &requeststore.Response{
+			Status:     http.StatusOK,
+			StatusText: "200 OK",
+			Proto:      "HTTP/1.1",
+			Headers:    map[string]string{"Content-Type": "text/plain"},
+			Body:       "OK\n",
+			Size:       3,
+		}, b.Store.GetAll()[0].Response)
+	})
+
+	t.Run("captures unknown bin and other requests in default store", func(t *testing.T) {
+		server, store, _ := newServer(t)
+
+		rec := httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/b/missing/x", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/x", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, 2, store.Count())
+		assert.Equal(t, "/b/missing/x", store.Since(0)[0].URL)
+	})
+}
+
+func TestDashboardPrefix(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "httpserver-dashboard-*.log")
+	require.NoError(t, err)
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+	tmpFile.Close()
+
+	store := requeststore.New(10)
+	dashboard := webui.New(store, ":9002", ":9002", webui.WithPrefix("/__debugger/"))
+	dashboardHandler, err := dashboard.Handler()
+	require.NoError(t, err)
+	server, err := httpserver.New(
+		httpserver.WithOutputWriter(tmpFile.Name()),
+		httpserver.WithStore(store),
+		httpserver.WithDashboard(dashboard.Prefix(), dashboardHandler),
+	)
+	require.NoError(t, err)
+	defer func() { _ = server.OutputWriter.Close() }()
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(`{}`)))
+
+		return rec
+	}
+
+	t.Run("serves dashboard and api without capturing", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/__debugger/")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+
+		rec = serve(http.MethodGet, "/__debugger/api/requests")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+
+		assert.Equal(t, 0, store.Count())
+	})
+
+	t.Run("redirects prefix without trailing slash", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/__debugger")
+
+		assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+		assert.Equal(t, "/__debugger/", rec.Header().Get("Location"))
+	})
+
+	t.Run("captures other paths", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/__debuggerx/hook")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		captured := store.GetAll()
+		require.Len(t, captured, 1)
+		assert.Equal(t, "/__debuggerx/hook", captured[0].URL)
+
+		rec = serve(http.MethodGet, "/__debugger/api/requests")
+		assert.Contains(t, rec.Body.String(), "/__debuggerx/hook")
+	})
+
+	t.Run("rejects invalid prefix", func(t *testing.T) {
+		for _, prefix := range []string{"", "/", "__debugger", "/{id}"} {
+			_, err := httpserver.New(httpserver.WithDashboard(prefix, dashboardHandler))
+
+			require.ErrorIs(t, err, httpserver.ErrInvalidValue, prefix)
+		}
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	collector := metrics.New()
+	server, err := httpserver.New(
+		httpserver.WithOutput(writerutils.NopCloser(io.Discard)),
+		httpserver.WithMetrics(collector),
+		httpserver.WithHMACSecret("secret"),
+		httpserver.WithHMACHeaderName("X-Signature"),
+		httpserver.WithResponse(&bin.Response{Status: http.StatusAccepted}),
+		httpserver.WithDashboard("/__debugger", http.NotFoundHandler()),
+	)
+	require.NoError(t, err)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`{"ok": true}`))
+
+	for _, signature := range []string{"sha256=" + hex.EncodeToString(mac.Sum(nil)), "sha256=invalid"} {
+		req := httptest.NewRequest(http.MethodPost, "/hooks/github?x=1", strings.NewReader(`{"ok": true}`))
+		req.Header.Set("X-Signature", signature)
+		server.HTTPServer.Handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	server.HTTPServer.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/__debugger/", nil))
+
+	var buf bytes.Buffer
+	require.NoError(t, collector.Write(&buf))
+
+	out := buf.String()
+	assert.Contains(t, out, `basichttpdebugger_requests_total{method="POST",path="/hooks/github",status="202"} 2`+"\n")
+	assert.NotContains(t, out, "/__debugger")
+	assert.Contains(t, out, "basichttpdebugger_request_body_bytes_sum 24\n")
+	assert.Contains(t, out, `basichttpdebugger_signature_validations_total{kind="hmac",result="fail"} 1`+"\n")
+	assert.Contains(t, out, `basichttpdebugger_signature_validations_total{kind="hmac",result="pass"} 1`+"\n")
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	store := requeststore.New(10)
+	server, err := httpserver.New(
+		httpserver.WithOutput(writerutils.NopCloser(io.Discard)),
+		httpserver.WithStore(store),
+	)
+	require.NoError(t, err)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		return rec
+	}
+
+	rec := get(httpserver.HealthPath)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, rec.Body.String())
+
+	rec = get(httpserver.VersionPath)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"version": "`+release.Version+`", "build": "`+release.BuildInformation+`"}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusServiceUnavailable, get(httpserver.ReadinessPath).Code)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(listener) }()
+
+	require.Eventually(t, func() bool {
+		return get(httpserver.ReadinessPath).Code == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, server.Stop())
+	require.NoError(t, <-done)
+	assert.Equal(t, http.StatusServiceUnavailable, get(httpserver.ReadinessPath).Code)
+
+	assert.Equal(t, 0, store.Count())
+
+	rec = httptest.NewRecorder()
+	server.HTTPServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, httpserver.HealthPath, nil))
+	assert.Equal(t, "OK\n", rec.Body.String())
+	assert.Equal(t, 1, store.Count())
+}
+
+func TestIgnore(t *testing.T) {
+	store := requeststore.New(10)
+	var out bytes.Buffer
+	collector := metrics.New()
+	server, err := httpserver.New(
+		httpserver.WithOutput(writerutils.NopCloser(&out)),
+		httpserver.WithStore(store),
+		httpserver.WithMetrics(collector),
+		httpserver.WithIgnoreUserAgents("kube-probe"),
+		httpserver.WithIgnorePaths("/ping", "/health/*"),
+		httpserver.WithResponse(&bin.Response{Status: http.StatusAccepted}),
+	)
+	require.NoError(t, err)
+
+	for target, userAgent := range map[string]string{
+		"/":             "kube-probe/1.29",
+		"/ping":         "curl/8.0",
+		"/health/live":  "",
+		"/hooks/github": "GitHub-Hookshot/abc",
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("User-Agent", userAgent)
+		rec := httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusAccepted, rec.Code, target)
+	}
+
+	requests := store.GetAll()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/hooks/github", requests[0].URL)
+	assert.Equal(t, 1, strings.Count(out.String(), "Basic HTTP Debugger"))
+
+	var buf bytes.Buffer
+	require.NoError(t, collector.Write(&buf))
+	assert.NotContains(t, buf.String(), `path="/ping"`)
+	assert.Contains(t, buf.String(),
+		`basichttpdebugger_skipped_requests_total{rule="ignore path=/ping",reason="ignored"} 1`)
+
+	_, err = httpserver.New(httpserver.WithIgnorePaths("/["))
+	require.ErrorIs(t, err, httpserver.ErrInvalidValue)
+
+	t.Run("answers skipped bin requests with bin response", func(t *testing.T) {
+		bins := bin.NewRegistry(10, 10)
+		b, err := bins.Create(bin.Config{
+			Token:    "alice",
+			Response: &bin.Response{Status: http.StatusCreated, Body: "bin"},
+		})
+		require.NoError(t, err)
+		server, err := httpserver.New(
+			httpserver.WithOutput(writerutils.NopCloser(io.Discard)),
+			httpserver.WithBins(bins),
+			httpserver.WithIgnorePaths("/b/*/ping"),
+			httpserver.WithResponse(&bin.Response{Status: http.StatusAccepted}),
+		)
+		require.NoError(t, err)
+
+		for _, target := range []string{"/b/alice/ping", "/b/alice/hooks"} {
+			rec := httptest.NewRecorder()
+			server.HTTPServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+			assert.Equal(t, http.StatusCreated, rec.Code, target)
+			assert.Equal(t, "bin", rec.Body.String(), target)
+		}
+		assert.Equal(t, 1, b.Store.Count())
+	})
+}
+
+func TestCaptureRules(t *testing.T) {
+	rules, err := capturerule.Parse(
+		"ignore cidr=10.0.0.0/8; include method=POST path=/hooks/* every=2; include header=X-Debug",
+	)
+	require.NoError(t, err)
+
+	store := requeststore.New(10)
+	collector := metrics.New()
+	server, err := httpserver.New(
+		httpserver.WithOutput(writerutils.NopCloser(io.Discard)),
+		httpserver.WithStore(store),
+		httpserver.WithMetrics(collector),
+		httpserver.WithCaptureRules(rules...),
+	)
+	require.NoError(t, err)
+
+	send := func(method, target, remoteAddr string, headers ...string) {
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = remoteAddr
+		for _, name := range headers {
+			req.Header.Set(name, "1")
+		}
+		rec := httptest.NewRecorder()
+		server.HTTPServer.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	send(http.MethodPost, "/hooks/a", "10.1.2.3:1234")
+	for range 3 {
+		send(http.MethodPost, "/hooks/b", "192.0.2.1:1234")
+	}
+	send(http.MethodGet, "/other", "192.0.2.1:1234")
+	send(http.MethodGet, "/other", "192.0.2.1:1234", "X-Debug")
+
+	var urls []string
+	for _, req := range store.GetAll() {
+		urls = append(urls, req.URL)
+	}
+	assert.Equal(t, []string{"/other", "/hooks/b", "/hooks/b"}, urls)
+
+	var buf bytes.Buffer
+	require.NoError(t, collector.Write(&buf))
+	for _, line := range []string{
+		`basichttpdebugger_skipped_requests_total{rule="",reason="not-included"} 1`,
+		`basichttpdebugger_skipped_requests_total{rule="ignore cidr=10.0.0.0/8",reason="ignored"} 1`,
+		`basichttpdebugger_skipped_requests_total{rule="include method=POST path=/hooks/* every=2",reason="sampled"} 1`,
+	} {
+		assert.Contains(t, buf.String(), line+"\n")
+	}
+
+	_, err = httpserver.New(httpserver.WithCaptureRules(capturerule.Rule{Action: "drop"}))
+	require.ErrorIs(t, err, httpserver.ErrInvalidValue)
+	require.ErrorIs(t, err, capturerule.ErrInvalidRule)
+}
@@ -0,0 +1,90 @@
+package httpserver
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/authorization"
+	"github.com/vbyazilim/basichttpdebugger/internal/metrics"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+// statusRecorder records the response status.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	return r.ResponseWriter.Write(p) //nolint:wrapcheck // transparent wrapper
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// countingReader counts bytes read from the request body.
+type countingReader struct {
+	io.ReadCloser
+
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+
+	return n, err //nolint:wrapcheck // transparent wrapper
+}
+
+// instrument records requests handled by next. Unread bodies are measured by
+// their content length.
+func instrument(m *metrics.Metrics, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		body := &countingReader{ReadCloser: r.Body}
+		r.Body = body
+
+		next(rec, r)
+
+		size := body.n
+		if size == 0 && r.ContentLength > 0 {
+			size = r.ContentLength
+		}
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		m.ObserveRequest(r.Method, r.URL.Path, status, size, time.Since(start))
+	}
+}
+
+// observeSignatures records signature validation results of a captured
+// request.
+func observeSignatures(m *metrics.Metrics, signature *requeststore.Signature, auth *authorization.Info) {
+	if signature != nil && signature.SecretToken != nil {
+		m.ObserveSignature(metrics.SignatureSecretToken, *signature.SecretToken)
+	}
+	if signature != nil && signature.HMAC != nil {
+		m.ObserveSignature(metrics.SignatureHMAC, *signature.HMAC)
+	}
+	if auth != nil && auth.JWT != nil && auth.JWT.Verified != nil {
+		m.ObserveSignature(metrics.SignatureJWT, *auth.JWT.Verified)
+	}
+}
@@ -0,0 +1,47 @@
+package httpserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/capturerule"
+)
+
+// captureRules returns ignore rules of ignored user agents and paths
+// followed by capture rules.
+func (s *DebugServer) captureRules() []capturerule.Rule {
+	rules := make([]capturerule.Rule, 0, len(s.IgnoreUserAgents)+len(s.IgnorePaths)+len(s.CaptureRules))
+	for _, userAgent := range s.IgnoreUserAgents {
+		rules = append(rules, capturerule.Rule{Action: capturerule.ActionIgnore, UserAgent: userAgent})
+	}
+	for _, pattern := range s.IgnorePaths {
+		rules = append(rules, capturerule.Rule{Action: capturerule.ActionIgnore, Path: pattern})
+	}
+
+	return append(rules, s.CaptureRules...)
+}
+
+// filter answers requests skipped by rules with the configured response, or
+// the response of their bin, without rendering, saving, storing or counting
+// them as captured, others are handled by next.
+func (s *DebugServer) filter(rules *capturerule.Rules, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decision := rules.Decide(r, time.Now())
+		if decision.Capture {
+			next(w, r)
+
+			return
+		}
+
+		if s.Metrics != nil {
+			s.Metrics.ObserveSkipped(decision.Rule, decision.Reason)
+		}
+		resp := s.response.Load()
+		if s.Bins != nil {
+			if b, _, err := s.Bins.Resolve(r); err == nil && b != nil {
+				resp = b.Response
+			}
+		}
+		writeResponse(w, resp)
+	}
+}
@@ -10,7 +10,11 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/capturerule"
 	"github.com/vbyazilim/basichttpdebugger/internal/envutils"
+	"github.com/vbyazilim/basichttpdebugger/internal/metrics"
 	"github.com/vbyazilim/basichttpdebugger/internal/release"
 	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
 	"github.com/vbyazilim/basichttpdebugger/internal/webui"
@@ -21,6 +25,8 @@ const (
 	helpSecretTokenHeaderName       = "name of your secret token header, e.g. X-Gitlab-Token"
 	defRawHTTPRequestFileSaveFormat = "%Y-%m-%d-%H%i%s-{hostname}-{url}.raw"
 	defWebDashboardMaxRequests      = 50
+	defMaxBins                      = 100
+	defMetricsMaxPaths              = 100
 )
 
 // Run creates server instance and runs.
@@ -41,6 +47,17 @@ func Run() error {
 		helpSecretTokenHeaderName,
 	)
 
+	jwtSecret := flag.String(
+		"jwt-secret",
+		envutils.GetenvOrDefault("JWT_SECRET", ""),
+		"HMAC secret for verifying JWT signatures in Authorization header",
+	)
+	jwksFile := flag.String(
+		"jwks-file",
+		envutils.GetenvOrDefault("JWKS_FILE", ""),
+		"JWKS file for verifying JWT signatures in Authorization header",
+	)
+
 	output := flag.String("output", envutils.GetenvOrDefault("OUTPUT", "stdout"), "output/write responses to")
 	color := flag.Bool("color", envutils.GetenvOrDefault("COLOR", false), "enable color")
 	saveRawHTTPRequest := flag.Bool(
@@ -53,11 +70,92 @@ func Run() error {
 		envutils.GetenvOrDefault("SAVE_FORMAT", defRawHTTPRequestFileSaveFormat),
 		"save filename format of raw http",
 	)
+	saveAs := flag.String(
+		"save-as",
+		envutils.GetenvOrDefault("SAVE_AS", SaveAsRaw),
+		"file format of saved http requests: raw, http (REST Client) or both",
+	)
+	saveUploads := flag.Bool(
+		"save-uploads",
+		envutils.GetenvOrDefault("SAVE_UPLOADS", false),
+		"enable saving of multipart file uploads",
+	)
+	uploadDirFormat := flag.String(
+		"upload-dir-format",
+		envutils.GetenvOrDefault("UPLOAD_DIR_FORMAT", defUploadDirFormat),
+		"directory name format of saved multipart uploads",
+	)
+	maxBodyMemory := flag.Int64(
+		"max-body-memory",
+		envutils.GetenvOrDefault("MAX_BODY_MEMORY", int64(defMaxBodyMemory)),
+		"max request body size kept in memory (bytes), larger bodies are spooled to disk, 0 disables",
+	)
+	spoolDir := flag.String(
+		"spool-dir",
+		envutils.GetenvOrDefault("SPOOL_DIR", os.TempDir()),
+		"directory for spooled request bodies",
+	)
 	webListen := flag.String(
 		"web-listen",
 		envutils.GetenvOrDefault("WEB_LISTEN", ""),
 		"web dashboard listen addr (default: debug port + 1)",
 	)
+	maxBins := flag.Int64(
+		"max-bins",
+		envutils.GetenvOrDefault("MAX_BINS", int64(defMaxBins)),
+		"max number of bins created via dashboard or api, 0 disables bins",
+	)
+	webAuth := flag.String(
+		"web-auth",
+		envutils.GetenvOrDefault("WEB_AUTH", ""),
+		"web dashboard admin basic auth credential, \"username:password\"",
+	)
+	webToken := flag.String("web-token", envutils.GetenvOrDefault("WEB_TOKEN", ""), "web dashboard admin bearer token")
+	webReadOnlyAuth := flag.String(
+		"web-readonly-auth",
+		envutils.GetenvOrDefault("WEB_READONLY_AUTH", ""),
+		"web dashboard read-only basic auth credential, \"username:password\"",
+	)
+	webReadOnlyToken := flag.String(
+		"web-readonly-token",
+		envutils.GetenvOrDefault("WEB_READONLY_TOKEN", ""),
+		"web dashboard read-only bearer token",
+	)
+	webCORSOrigins := flag.String(
+		"web-cors-origins",
+		envutils.GetenvOrDefault("WEB_CORS_ORIGINS", ""),
+		"comma separated origins allowed to call web dashboard api",
+	)
+	webPrefix := flag.String(
+		"web-prefix",
+		envutils.GetenvOrDefault("WEB_PREFIX", ""),
+		"serve web dashboard on the debug listener under this path prefix, e.g. /__debugger/ (single port mode)",
+	)
+	metricsEnabled := flag.Bool(
+		"metrics",
+		envutils.GetenvOrDefault("METRICS", true),
+		"serve prometheus metrics at /metrics of web dashboard",
+	)
+	metricsMaxPaths := flag.Int64(
+		"metrics-max-paths",
+		envutils.GetenvOrDefault("METRICS_MAX_PATHS", int64(defMetricsMaxPaths)),
+		"max number of distinct path labels of /metrics, 0 counts all paths as \"other\"",
+	)
+	ignoreUserAgents := flag.String(
+		"ignore-user-agents",
+		envutils.GetenvOrDefault("IGNORE_USER_AGENTS", ""),
+		"comma separated user agents (substrings) of requests not to capture, e.g. kube-probe",
+	)
+	ignorePaths := flag.String(
+		"ignore-paths",
+		envutils.GetenvOrDefault("IGNORE_PATHS", ""),
+		"comma separated path globs of requests not to capture, e.g. /ping,/health/*",
+	)
+	captureRules := flag.String(
+		"capture-rules",
+		envutils.GetenvOrDefault("CAPTURE_RULES", ""),
+		"\";\" separated capture rules, e.g. \"ignore cidr=10.0.0.0/8; include method=POST path=/hooks/* every=10\"",
+	)
 	version := flag.Bool("version", false, "display version information")
 	flag.Parse() //nolint:revive
 
@@ -67,20 +165,77 @@ func Run() error {
 		return nil
 	}
 
+	// servers strip colors unless enabled for them, color support of the
+	// process is detected from the environment otherwise
+	if *color {
+		text.EnableColors()
+	}
+
+	webOptions, err := webuiOptions(*webAuth, *webToken, *webReadOnlyAuth, *webReadOnlyToken, *webCORSOrigins)
+	if err != nil {
+		return fmt.Errorf("web dashboard init error: %w", err)
+	}
+
+	rules, err := capturerule.Parse(*captureRules)
+	if err != nil {
+		return fmt.Errorf("capture rules error: %w", err)
+	}
+
 	store := requeststore.New(defWebDashboardMaxRequests)
 
-	server, err := New(
+	var bins *bin.Registry
+	if *maxBins > 0 {
+		bins = bin.NewRegistry(int(*maxBins), defWebDashboardMaxRequests)
+	}
+
+	var collector *metrics.Metrics
+	if *metricsEnabled {
+		collector = metrics.New(metrics.WithMaxPaths(int(*metricsMaxPaths)))
+		webOptions = append(webOptions, webui.WithMetrics(collector))
+	}
+
+	webListenAddr := *webListen
+	if *webPrefix != "" {
+		webListenAddr = *listenAddr
+		webOptions = append(webOptions, webui.WithPrefix(*webPrefix))
+	} else if webListenAddr == "" {
+		webListenAddr = calculateWebPort(*listenAddr)
+	}
+	webServer := webui.New(store, webListenAddr, *listenAddr, append(webOptions, webui.WithBins(bins))...)
+
+	serverOptions := []Option{
 		WithListenAddr(*listenAddr),
 		WithHMACHeaderName(*hmacHeaderName),
 		WithHMACSecret(*hmacSecretValue),
 		WithSecretToken(*secretToken),
 		WithSecretTokenHeaderName(*secretTokenHeaderName),
+		WithJWTSecret(*jwtSecret),
+		WithJWKSFile(*jwksFile),
 		WithOutputWriter(*output),
 		WithColor(*color),
 		WithSaveRawHTTPRequest(*saveRawHTTPRequest),
 		WithRawHTTPRequestFileSaveFormat(*saveFormat),
+		WithSaveAs(*saveAs),
+		WithSaveUploads(*saveUploads),
+		WithUploadDirFormat(*uploadDirFormat),
+		WithMaxBodyMemory(*maxBodyMemory),
+		WithSpoolDir(*spoolDir),
 		WithStore(store),
-	)
+		WithBins(bins),
+		WithMetrics(collector),
+		WithIgnoreUserAgents(splitList(*ignoreUserAgents)...),
+		WithIgnorePaths(splitList(*ignorePaths)...),
+		WithCaptureRules(rules...),
+	}
+	if *webPrefix != "" {
+		dashboard, dashboardErr := webServer.Handler()
+		if dashboardErr != nil {
+			return fmt.Errorf("web dashboard init error: %w", dashboardErr)
+		}
+		serverOptions = append(serverOptions, WithDashboard(webServer.Prefix(), dashboard))
+	}
+
+	server, err := New(serverOptions...)
 	if err != nil {
 		return fmt.Errorf("server init error: %w", err)
 	}
@@ -91,17 +246,13 @@ func Run() error {
 		}
 	}()
 
-	webListenAddr := *webListen
-	if webListenAddr == "" {
-		webListenAddr = calculateWebPort(*listenAddr)
+	if *webPrefix == "" {
+		go func() {
+			if webErr := webServer.Start(); webErr != nil {
+				log.Printf("web dashboard error: %v", webErr)
+			}
+		}()
 	}
-	webServer := webui.New(store, webListenAddr, *listenAddr)
-
-	go func() {
-		if webErr := webServer.Start(); webErr != nil {
-			log.Printf("web dashboard error: %v", webErr)
-		}
-	}()
 
 	closed := make(chan struct{})
 
@@ -133,6 +284,50 @@ func Run() error {
 	return nil
 }
 
+// webuiOptions returns web dashboard options of given credentials and
+// comma separated cors origins.
+func webuiOptions(auth, token, readOnlyAuth, readOnlyToken, corsOrigins string) ([]webui.Option, error) {
+	var credentials []webui.Credential
+	for _, basic := range []struct{ value, role string }{
+		{auth, webui.RoleAdmin},
+		{readOnlyAuth, webui.RoleReadOnly},
+	} {
+		if basic.value == "" {
+			continue
+		}
+		credential, err := webui.ParseBasicCredential(basic.value, basic.role)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	if token != "" {
+		credentials = append(credentials, webui.Credential{Token: token, Role: webui.RoleAdmin})
+	}
+	if readOnlyToken != "" {
+		credentials = append(credentials, webui.Credential{Token: readOnlyToken, Role: webui.RoleReadOnly})
+	}
+
+	origins, err := webui.ParseCORSOrigins(corsOrigins)
+	if err != nil {
+		return nil, err
+	}
+
+	return []webui.Option{webui.WithCredentials(credentials...), webui.WithCORSOrigins(origins...)}, nil
+}
+
+// splitList splits comma separated values, empty values are skipped.
+func splitList(s string) []string {
+	var values []string
+	for value := range strings.SplitSeq(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
 func calculateWebPort(listenAddr string) string {
 	parts := strings.Split(listenAddr, ":")
 	if len(parts) != 2 {
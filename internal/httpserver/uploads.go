@@ -0,0 +1,70 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/stringutils"
+)
+
+const (
+	defUploadFilename = "upload.bin"
+	uploadDirPerm     = 0o750
+	uploadFilePerm    = 0o600
+)
+
+// makeUploadDir creates a fresh directory for uploads of a single request.
+// A counter is appended if dir already exists, requests received within the
+// same second never share a directory.
+func makeUploadDir(dir string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dir), uploadDirPerm); err != nil {
+		return "", fmt.Errorf("upload dir create error: %w", err)
+	}
+
+	candidate := dir
+	for i := 2; ; i++ {
+		err := os.Mkdir(candidate, uploadDirPerm)
+		if err == nil {
+			return candidate, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", fmt.Errorf("upload dir create error: %w", err)
+		}
+
+		candidate = dir + "-" + strconv.Itoa(i)
+	}
+}
+
+// createUploadFile creates a new file in dir for an uploaded file. Client
+// supplied filename is sanitized, duplicate names get a counter suffix.
+func createUploadFile(dir, filename string) (*os.File, error) {
+	name := stringutils.SanitizeFilename(filepath.Base(filename))
+	if name == "" || name == "." || name == ".." {
+		name = defUploadFilename
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	candidate := name
+	for i := 2; ; i++ {
+		f, err := os.OpenFile(
+			filepath.Join(dir, candidate),
+			os.O_WRONLY|os.O_CREATE|os.O_EXCL,
+			uploadFilePerm,
+		)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("upload file create error: %w", err)
+		}
+
+		candidate = base + "-" + strconv.Itoa(i) + ext
+	}
+}
@@ -0,0 +1,285 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	namespace   = "basichttpdebugger"
+	defMaxPaths = 100
+
+	// OtherPath is the path label of requests to paths beyond the max number
+	// of paths, OtherMethod the method label of non standard methods.
+	OtherPath   = "other"
+	OtherMethod = "OTHER"
+)
+
+// signature kinds.
+const (
+	SignatureSecretToken = "secret_token"
+	SignatureHMAC        = "hmac"
+	SignatureJWT         = "jwt"
+)
+
+var (
+	bodySizeBuckets = []float64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+	durationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	methods = []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+	}
+)
+
+type requestLabels struct {
+	method string
+	path   string
+	status int
+}
+
+type skipLabels struct {
+	rule   string
+	reason string
+}
+
+type signatureLabels struct {
+	kind  string
+	valid bool
+}
+
+// Metrics collects captured request metrics, safe for concurrent use.
+type Metrics struct {
+	mu         sync.Mutex
+	requests   map[requestLabels]uint64
+	paths      map[string]struct{}
+	signatures map[signatureLabels]uint64
+	skipped    map[skipLabels]uint64
+	bodySize   *histogram
+	duration   *histogram
+	maxPaths   int
+}
+
+// Option represents option function type.
+type Option func(*Metrics)
+
+// WithMaxPaths sets the max number of distinct path labels, requests to
+// other paths are counted as OtherPath, 0 counts all of them as OtherPath.
+// Default is 100.
+func WithMaxPaths(n int) Option {
+	return func(m *Metrics) {
+		m.maxPaths = n
+	}
+}
+
+// New creates a new Metrics instance.
+func New(options ...Option) *Metrics {
+	m := &Metrics{
+		requests:   make(map[requestLabels]uint64),
+		paths:      make(map[string]struct{}),
+		signatures: make(map[signatureLabels]uint64),
+		skipped:    make(map[skipLabels]uint64),
+		bodySize:   newHistogram(bodySizeBuckets),
+		duration:   newHistogram(durationBuckets),
+		maxPaths:   defMaxPaths,
+	}
+
+	for _, option := range options {
+		option(m)
+	}
+
+	return m
+}
+
+// ObserveRequest records a handled request.
+func (m *Metrics) ObserveRequest(method, path string, status int, bodySize int64, duration time.Duration) {
+	if !slices.Contains(methods, method) {
+		method = OtherMethod
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.paths[path]; !ok {
+		if len(m.paths) >= m.maxPaths {
+			path = OtherPath
+		} else {
+			m.paths[path] = struct{}{}
+		}
+	}
+
+	m.requests[requestLabels{method: method, path: path, status: status}]++
+	m.bodySize.observe(float64(bodySize))
+	m.duration.observe(duration.Seconds())
+}
+
+// ObserveSignature records a signature validation result of given kind.
+func (m *Metrics) ObserveSignature(kind string, valid bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.signatures[signatureLabels{kind: kind, valid: valid}]++
+}
+
+// ObserveSkipped records a request not captured because of given rule and
+// reason, see capturerule.Decision.
+func (m *Metrics) ObserveSkipped(rule, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.skipped[skipLabels{rule: rule, reason: reason}]++
+}
+
+// Write writes collected metrics in Prometheus text exposition format.
+func (m *Metrics) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	m.write(&buf)
+
+	if _, err := buf.WriteTo(w); err != nil {
+		return fmt.Errorf("write metrics: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeHeader(w, "requests_total", "counter", "Captured requests by method, path and response status.")
+	requests := make([]requestLabels, 0, len(m.requests))
+	for labels := range m.requests {
+		requests = append(requests, labels)
+	}
+	slices.SortFunc(requests, func(a, b requestLabels) int {
+		return strings.Compare(
+			a.method+" "+a.path+" "+strconv.Itoa(a.status),
+			b.method+" "+b.path+" "+strconv.Itoa(b.status),
+		)
+	})
+	for _, labels := range requests {
+		writeSample(w, "requests_total", m.requests[labels],
+			"method", labels.method, "path", labels.path, "status", strconv.Itoa(labels.status))
+	}
+
+	writeHeader(w, "request_body_bytes", "histogram", "Body size of captured requests.")
+	m.bodySize.write(w, "request_body_bytes")
+
+	writeHeader(w, "request_duration_seconds", "histogram", "Handling time of captured requests.")
+	m.duration.write(w, "request_duration_seconds")
+
+	writeHeader(w, "signature_validations_total", "counter",
+		"Signature validations of captured requests by kind and result.")
+	signatures := make([]signatureLabels, 0, len(m.signatures))
+	for labels := range m.signatures {
+		signatures = append(signatures, labels)
+	}
+	slices.SortFunc(signatures, func(a, b signatureLabels) int {
+		return strings.Compare(a.kind+" "+result(a.valid), b.kind+" "+result(b.valid))
+	})
+	for _, labels := range signatures {
+		writeSample(w, "signature_validations_total", m.signatures[labels],
+			"kind", labels.kind, "result", result(labels.valid))
+	}
+
+	writeHeader(w, "skipped_requests_total", "counter", "Requests not captured by capture rule and reason.")
+	skipped := make([]skipLabels, 0, len(m.skipped))
+	for labels := range m.skipped {
+		skipped = append(skipped, labels)
+	}
+	slices.SortFunc(skipped, func(a, b skipLabels) int {
+		return strings.Compare(a.rule+"\x00"+a.reason, b.rule+"\x00"+b.reason)
+	})
+	for _, labels := range skipped {
+		writeSample(w, "skipped_requests_total", m.skipped[labels], "rule", labels.rule, "reason", labels.reason)
+	}
+}
+
+// WriteMetric writes a single sample metric without labels, e.g. a gauge
+// collected on scrape.
+func WriteMetric(w io.Writer, name, metricType, help string, value float64) {
+	writeHeader(w, name, metricType, help)
+	writeSample(w, name, value)
+}
+
+func result(valid bool) string {
+	if valid {
+		return "pass"
+	}
+
+	return "fail"
+}
+
+func writeHeader(w io.Writer, name, metricType, help string) {
+	fmt.Fprintf(w, "# HELP %s_%s %s\n", namespace, name, help)
+	fmt.Fprintf(w, "# TYPE %s_%s %s\n", namespace, name, metricType)
+}
+
+// writeSample writes a sample with given label name and value pairs.
+func writeSample[T uint64 | float64](w io.Writer, name string, value T, labels ...string) {
+	var b strings.Builder
+	b.WriteString(namespace + "_" + name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i] + `="` + escapeLabel(labels[i+1]) + `"`)
+		}
+		b.WriteByte('}')
+	}
+
+	fmt.Fprintf(w, "%s %s\n", b.String(), formatFloat(float64(value)))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// histogram keeps cumulative bucket counts.
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bucket := range h.buckets {
+		if v <= bucket {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name string) {
+	for i, bucket := range h.buckets {
+		writeSample(w, name+"_bucket", h.counts[i], "le", formatFloat(bucket))
+	}
+	writeSample(w, name+"_bucket", h.count, "le", "+Inf")
+	writeSample(w, name+"_sum", h.sum)
+	writeSample(w, name+"_count", h.count)
+}
@@ -0,0 +1,67 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/internal/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New(metrics.WithMaxPaths(2))
+	m.ObserveRequest(http.MethodPost, "/hooks/github", http.StatusOK, 512, 2*time.Millisecond)
+	m.ObserveRequest(http.MethodPost, "/hooks/github", http.StatusOK, 2048, 20*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, `/a"b`, http.StatusAccepted, 0, time.Millisecond)
+	m.ObserveRequest("PROPFIND", "/c", http.StatusOK, 0, time.Millisecond)
+	m.ObserveSignature(metrics.SignatureHMAC, true)
+	m.ObserveSignature(metrics.SignatureHMAC, false)
+	m.ObserveSignature(metrics.SignatureJWT, false)
+	m.ObserveSkipped(`ignore ua="bot"`, "ignored")
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Write(&buf))
+	metrics.WriteMetric(&buf, "sse_subscribers", "gauge", "Subscribers.", 3)
+
+	out := buf.String()
+	for _, line := range []string{
+		"# TYPE basichttpdebugger_requests_total counter",
+		`basichttpdebugger_requests_total{method="POST",path="/hooks/github",status="200"} 2`,
+		`basichttpdebugger_requests_total{method="GET",path="/a\"b",status="202"} 1`,
+		`basichttpdebugger_requests_total{method="OTHER",path="other",status="200"} 1`,
+		"# TYPE basichttpdebugger_request_body_bytes histogram",
+		`basichttpdebugger_request_body_bytes_bucket{le="256"} 2`,
+		`basichttpdebugger_request_body_bytes_bucket{le="1024"} 3`,
+		`basichttpdebugger_request_body_bytes_bucket{le="1048576"} 4`,
+		`basichttpdebugger_request_body_bytes_bucket{le="+Inf"} 4`,
+		"basichttpdebugger_request_body_bytes_sum 2560",
+		"basichttpdebugger_request_body_bytes_count 4",
+		`basichttpdebugger_request_duration_seconds_bucket{le="0.001"} 2`,
+		`basichttpdebugger_request_duration_seconds_bucket{le="0.005"} 3`,
+		`basichttpdebugger_request_duration_seconds_bucket{le="0.025"} 4`,
+		"basichttpdebugger_request_duration_seconds_count 4",
+		`basichttpdebugger_signature_validations_total{kind="hmac",result="fail"} 1`,
+		`basichttpdebugger_signature_validations_total{kind="hmac",result="pass"} 1`,
+		`basichttpdebugger_signature_validations_total{kind="jwt",result="fail"} 1`,
+		`basichttpdebugger_skipped_requests_total{rule="ignore ua=\"bot\"",reason="ignored"} 1`,
+		"# TYPE basichttpdebugger_sse_subscribers gauge",
+		"basichttpdebugger_sse_subscribers 3",
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+}
+
+func TestMetrics_noPathLabels(t *testing.T) {
+	m := metrics.New(metrics.WithMaxPaths(0))
+	m.ObserveRequest(http.MethodPost, "/hooks/github", http.StatusOK, 0, time.Millisecond)
+	m.ObserveRequest(http.MethodPost, "/hooks/gitlab", http.StatusOK, 0, time.Millisecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Write(&buf))
+
+	assert.Contains(t, buf.String(), `basichttpdebugger_requests_total{method="POST",path="other",status="200"} 2`+"\n")
+	assert.NotContains(t, buf.String(), "/hooks/")
+}
@@ -0,0 +1,152 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const rawFileExt = ".raw"
+
+// sentinel errors.
+var (
+	ErrInvalidRequestLine = errors.New("invalid request line")
+	ErrNoFiles            = errors.New("no raw files found")
+)
+
+// RawRequest is a request parsed from a raw http request file.
+type RawRequest struct {
+	Method     string
+	RequestURI string
+	Proto      string
+	Host       string
+	Header     http.Header
+	Body       []byte
+}
+
+// ParseRawFile parses a raw http request file saved with
+// -save-raw-http-request.
+func ParseRawFile(path string) (*RawRequest, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("raw file read error: %w", err)
+	}
+
+	return ParseRaw(data)
+}
+
+// ParseRaw parses a raw http request. Both CRLF and LF line endings are
+// accepted. Saved files end the body with an extra newline, it is dropped
+// unless Content-Length says otherwise.
+func ParseRaw(data []byte) (*RawRequest, error) {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+
+	line, err := reader.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequestLine, err)
+	}
+
+	parts := strings.Fields(line)
+	if len(parts) != 3 || !strings.HasPrefix(parts[2], "HTTP/") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRequestLine, line)
+	}
+
+	mimeHeader, err := reader.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("header parse error: %w", err)
+	}
+
+	body, err := io.ReadAll(reader.R)
+	if err != nil {
+		return nil, fmt.Errorf("body read error: %w", err)
+	}
+
+	header := http.Header(mimeHeader)
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	req := &RawRequest{
+		Method:     parts[0],
+		RequestURI: parts[1],
+		Proto:      parts[2],
+		Host:       header.Get("Host"),
+		Header:     header,
+		Body:       trimBody(body, header.Get("Content-Length")),
+	}
+	header.Del("Host")
+	header.Del("Content-Length")
+	header.Del("Transfer-Encoding")
+
+	return req, nil
+}
+
+func trimBody(body []byte, contentLength string) []byte {
+	if n, err := strconv.Atoi(contentLength); err == nil && n >= 0 && n <= len(body) {
+		return body[:n]
+	}
+
+	body = bytes.TrimSuffix(body, []byte("\n"))
+
+	return bytes.TrimSuffix(body, []byte("\r"))
+}
+
+// CollectFiles expands given files, directories (all .raw files inside) and
+// glob patterns to a sorted list of files.
+func CollectFiles(args []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, arg := range args {
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoFiles, arg)
+		}
+
+		for _, match := range matches {
+			info, errStat := os.Stat(match)
+			if errStat != nil {
+				return nil, fmt.Errorf("stat error: %w", errStat)
+			}
+			if !info.IsDir() {
+				add(match)
+
+				continue
+			}
+
+			dirFiles, errGlob := filepath.Glob(filepath.Join(match, "*"+rawFileExt))
+			if errGlob != nil {
+				return nil, fmt.Errorf("invalid directory %q: %w", match, errGlob)
+			}
+			for _, f := range dirFiles {
+				add(f)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, ErrNoFiles
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
@@ -0,0 +1,95 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRaw(t *testing.T) {
+	t.Run("saved file with body", func(t *testing.T) {
+		raw := "POST /webhook?x=1 HTTP/1.1\n" +
+			"Host: localhost:9002\n" +
+			"Content-Length: 16\n" +
+			"Content-Type: application/json\n" +
+			"X-Multi: a,b\n" +
+			"\n" +
+			"{\"test\": \"data\"}\n"
+
+		req, err := ParseRaw([]byte(raw))
+		require.NoError(t, err)
+
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "/webhook?x=1", req.RequestURI)
+		assert.Equal(t, "HTTP/1.1", req.Proto)
+		assert.Equal(t, "localhost:9002", req.Host)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "a,b", req.Header.Get("X-Multi"))
+		assert.Empty(t, req.Header.Get("Host"))
+		assert.Empty(t, req.Header.Get("Content-Length"))
+		assert.Equal(t, `{"test": "data"}`, string(req.Body))
+	})
+
+	t.Run("body without content length keeps inner newlines", func(t *testing.T) {
+		raw := "PUT /a HTTP/1.1\r\nHost: h\r\n\r\nline1\nline2\n\n"
+
+		req, err := ParseRaw([]byte(raw))
+		require.NoError(t, err)
+		assert.Equal(t, "line1\nline2\n", string(req.Body))
+	})
+
+	t.Run("request without body", func(t *testing.T) {
+		req, err := ParseRaw([]byte("GET / HTTP/1.1\nHost: h\nAccept: */*\n"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "GET", req.Method)
+		assert.Equal(t, "*/*", req.Header.Get("Accept"))
+		assert.Empty(t, req.Body)
+	})
+
+	t.Run("invalid request line", func(t *testing.T) {
+		_, err := ParseRaw([]byte("hello world\n"))
+		assert.ErrorIs(t, err, ErrInvalidRequestLine)
+
+		_, err = ParseRaw(nil)
+		assert.ErrorIs(t, err, ErrInvalidRequestLine)
+	})
+}
+
+func TestCollectFiles(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "fixtures")
+	require.NoError(t, os.Mkdir(sub, 0o750))
+
+	for _, name := range []string{"a.raw", "b.raw", "fixtures/c.raw", "fixtures/notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("GET / HTTP/1.1\n"), 0o600))
+	}
+
+	t.Run("files, directories and globs", func(t *testing.T) {
+		files, err := CollectFiles([]string{
+			filepath.Join(dir, "*.raw"),
+			sub,
+			filepath.Join(dir, "a.raw"),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			filepath.Join(dir, "a.raw"),
+			filepath.Join(dir, "b.raw"),
+			filepath.Join(sub, "c.raw"),
+		}, files)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		_, err := CollectFiles([]string{filepath.Join(dir, "*.missing")})
+		assert.ErrorIs(t, err, ErrNoFiles)
+	})
+
+	t.Run("no args", func(t *testing.T) {
+		_, err := CollectFiles(nil)
+		assert.ErrorIs(t, err, ErrNoFiles)
+	})
+}
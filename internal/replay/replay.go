@@ -0,0 +1,230 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defTimeout     = 10 * time.Second
+	headerReplayed = "X-Replayed-From"
+	statusFailed   = http.StatusBadRequest // responses with status >= this are failures
+)
+
+// ErrReplayFailed is returned when at least one request fails.
+var ErrReplayFailed = errors.New("replay failed")
+
+// Options configures a replay run.
+type Options struct {
+	Target      *url.URL
+	Headers     http.Header // overrides, empty value removes the header
+	Client      *http.Client
+	Concurrency int
+	Rate        float64 // requests per second, 0 is unlimited
+}
+
+// Result is the outcome of replaying a single file.
+type Result struct {
+	File       string
+	Status     string
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+}
+
+// Failed reports whether the request could not be sent or got an error
+// response.
+func (r Result) Failed() bool {
+	return r.Err != nil || r.StatusCode >= statusFailed
+}
+
+// Summary holds aggregated results of a replay run.
+type Summary struct {
+	Total      int
+	Succeeded  int
+	Failed     int
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	AvgLatency time.Duration
+
+	latencySum time.Duration
+	measured   int
+}
+
+func (s *Summary) add(r Result) {
+	s.Total++
+	if r.Failed() {
+		s.Failed++
+	} else {
+		s.Succeeded++
+	}
+
+	if r.Err != nil {
+		return
+	}
+
+	if s.MinLatency == 0 || r.Latency < s.MinLatency {
+		s.MinLatency = r.Latency
+	}
+	if r.Latency > s.MaxLatency {
+		s.MaxLatency = r.Latency
+	}
+	s.latencySum += r.Latency
+	s.measured++
+	s.AvgLatency = s.latencySum / time.Duration(s.measured)
+}
+
+// Replay sends given raw files to target and reports each result to onResult
+// as it completes. onResult calls are serialized.
+func Replay(ctx context.Context, files []string, opts Options, onResult func(Result)) Summary {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: defTimeout}
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	jobs := make(chan string)
+	go func() {
+		defer close(jobs)
+
+		var tick <-chan time.Time
+		if opts.Rate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for i, file := range files {
+			if tick != nil && i > 0 {
+				select {
+				case <-tick:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case jobs <- file:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		summary Summary
+	)
+
+	for range opts.Concurrency {
+		wg.Go(func() {
+			for file := range jobs {
+				result := replayFile(ctx, file, opts)
+
+				mu.Lock()
+				summary.add(result)
+				if onResult != nil {
+					onResult(result)
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	return summary
+}
+
+func replayFile(ctx context.Context, file string, opts Options) Result {
+	result := Result{File: file}
+
+	raw, err := ParseRawFile(file)
+	if err != nil {
+		result.Err = err
+
+		return result
+	}
+
+	req, err := buildRequest(ctx, raw, opts, filepath.Base(file))
+	if err != nil {
+		result.Err = err
+
+		return result
+	}
+
+	start := time.Now()
+	resp, err := opts.Client.Do(req)
+	if err != nil {
+		result.Err = fmt.Errorf("request error: %w", err)
+
+		return result
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	result.Latency = time.Since(start)
+	result.Status = resp.Status
+	result.StatusCode = resp.StatusCode
+
+	return result
+}
+
+func buildRequest(ctx context.Context, raw *RawRequest, opts Options, name string) (*http.Request, error) {
+	original, err := url.ParseRequestURI(raw.RequestURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request uri: %w", err)
+	}
+
+	target := *opts.Target
+	target.Path = strings.TrimSuffix(target.Path, "/") + original.Path
+	target.RawPath = ""
+	target.RawQuery = original.RawQuery
+
+	req, err := http.NewRequestWithContext(ctx, raw.Method, target.String(), bytes.NewReader(raw.Body))
+	if err != nil {
+		return nil, fmt.Errorf("request create error: %w", err)
+	}
+	// the path is sent as saved, re-encoding the parsed one changes e.g. %2F
+	// to /
+	req.URL.Opaque = strings.TrimSuffix(opts.Target.EscapedPath(), "/") + rawPath(raw.RequestURI, original)
+
+	req.Header = raw.Header.Clone()
+	req.Header.Set(headerReplayed, name)
+	for key, values := range opts.Headers {
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			req.Header.Del(key)
+
+			continue
+		}
+		req.Header[key] = values
+	}
+
+	return req, nil
+}
+
+// rawPath returns the path of request uri as is, original is the parsed uri.
+func rawPath(requestURI string, original *url.URL) string {
+	path, _, _ := strings.Cut(requestURI, "?")
+	if original.Scheme != "" {
+		// absolute-form, e.g. http://host/path
+		path = strings.TrimPrefix(path, original.Scheme+"://")
+		if i := strings.IndexByte(path, '/'); i >= 0 {
+			path = path[i:]
+		} else {
+			path = "/"
+		}
+	}
+
+	return path
+}
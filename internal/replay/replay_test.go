@@ -0,0 +1,160 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturedRequest struct {
+	method string
+	uri    string
+	header http.Header
+	body   string
+}
+
+func newTestTarget(t *testing.T, status int) (*httptest.Server, *[]capturedRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var captured []capturedRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		captured = append(captured, capturedRequest{r.Method, r.RequestURI, r.Header.Clone(), string(body)})
+		mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &captured
+}
+
+func writeRawFiles(t *testing.T, n int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for i := range n {
+		raw := "POST /hook?n=" + string(rune('0'+i)) + " HTTP/1.1\n" +
+			"Host: original:9002\n" +
+			"Content-Type: text/plain\n" +
+			"X-Secret: abc\n" +
+			"\n" +
+			"payload\n"
+		name := filepath.Join(dir, "req-"+string(rune('0'+i))+".raw")
+		require.NoError(t, os.WriteFile(name, []byte(raw), 0o600))
+	}
+
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	t.Run("replays directory with header overrides", func(t *testing.T) {
+		server, captured := newTestTarget(t, http.StatusOK)
+		dir := writeRawFiles(t, 3)
+
+		var out bytes.Buffer
+		err := run(context.Background(), []string{
+			"-target", server.URL + "/base/",
+			"-H", "X-Secret:",
+			"-H", "Authorization: Bearer t",
+			"-concurrency", "2",
+			dir,
+		}, &out)
+		require.NoError(t, err, out.String())
+
+		require.Len(t, *captured, 3)
+		for _, req := range *captured {
+			assert.Equal(t, "POST", req.method)
+			assert.Contains(t, req.uri, "/base/hook?n=")
+			assert.Equal(t, "payload", req.body)
+			assert.Equal(t, "text/plain", req.header.Get("Content-Type"))
+			assert.Equal(t, "Bearer t", req.header.Get("Authorization"))
+			assert.Empty(t, req.header.Get("X-Secret"))
+			assert.Contains(t, req.header.Get("X-Replayed-From"), ".raw")
+		}
+
+		assert.Contains(t, out.String(), "req-0.raw")
+		assert.Contains(t, out.String(), "files: 3, succeeded: 3, failed: 0")
+	})
+
+	t.Run("sends encoded paths as saved", func(t *testing.T) {
+		server, captured := newTestTarget(t, http.StatusOK)
+		dir := t.TempDir()
+		raw := "GET /files/a%2Fb/%7Euser?q=a%20b HTTP/1.1\nHost: original:9002\n\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "req.raw"), []byte(raw), 0o600))
+
+		var out bytes.Buffer
+		require.NoError(t, run(context.Background(), []string{"-target", server.URL + "/base/", dir}, &out), out.String())
+
+		require.Len(t, *captured, 1)
+		assert.Equal(t, "/base/files/a%2Fb/%7Euser?q=a%20b", (*captured)[0].uri)
+	})
+
+	t.Run("reports failures", func(t *testing.T) {
+		server, _ := newTestTarget(t, http.StatusInternalServerError)
+		dir := writeRawFiles(t, 1)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.raw"), []byte("nonsense"), 0o600))
+
+		var out bytes.Buffer
+		err := run(context.Background(), []string{"-target", server.URL, dir}, &out)
+
+		require.ErrorIs(t, err, ErrReplayFailed)
+		assert.Contains(t, out.String(), "ERR")
+		assert.Contains(t, out.String(), "500")
+		assert.Contains(t, out.String(), "files: 2, succeeded: 0, failed: 2")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := run(context.Background(), []string{"-target", "localhost", "x.raw"}, &out)
+		require.ErrorIs(t, err, ErrInvalidTarget)
+
+		err = run(context.Background(), []string{"-H", "novalue", "x.raw"}, &out)
+		require.ErrorContains(t, err, ErrInvalidHeader.Error())
+
+		err = run(context.Background(), []string{filepath.Join(t.TempDir(), "*.raw")}, &out)
+		require.ErrorIs(t, err, ErrNoFiles)
+
+		require.NoError(t, run(context.Background(), []string{"-h"}, &out))
+	})
+}
+
+func TestReplay_rateLimit(t *testing.T) {
+	server, _ := newTestTarget(t, http.StatusOK)
+	dir := writeRawFiles(t, 3)
+	files, err := CollectFiles([]string{dir})
+	require.NoError(t, err)
+
+	target, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	var calls atomic.Int32
+	start := time.Now()
+	summary := Replay(context.Background(), files, Options{
+		Target:      target.URL,
+		Concurrency: 3,
+		Rate:        20, // 50ms apart
+	}, func(Result) { calls.Add(1) })
+
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 3, summary.Succeeded)
+	assert.Positive(t, summary.MinLatency)
+	assert.LessOrEqual(t, summary.MinLatency, summary.AvgLatency)
+	assert.LessOrEqual(t, summary.AvgLatency, summary.MaxLatency)
+}
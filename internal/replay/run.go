@@ -0,0 +1,138 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/envutils"
+)
+
+const (
+	defTarget      = "http://localhost:9002"
+	defConcurrency = 1
+)
+
+// sentinel errors.
+var (
+	ErrInvalidHeader = errors.New("invalid header, expected \"Name: value\"")
+	ErrInvalidTarget = errors.New("invalid target url")
+)
+
+// headerFlag collects repeated -H flags.
+type headerFlag struct {
+	header http.Header
+}
+
+func (h *headerFlag) String() string {
+	if h.header == nil {
+		return ""
+	}
+
+	parts := make([]string, 0, len(h.header))
+	for key, values := range h.header {
+		parts = append(parts, key+": "+strings.Join(values, ","))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (h *headerFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidHeader, value)
+	}
+
+	if h.header == nil {
+		h.header = make(http.Header)
+	}
+	h.header.Add(name, strings.TrimSpace(val))
+
+	return nil
+}
+
+// Run runs the replay subcommand with given arguments.
+func Run(args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return run(ctx, args, os.Stdout)
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: basichttpdebugger replay [flags] <file|dir|glob>...")
+		fs.PrintDefaults()
+	}
+
+	var headers headerFlag
+
+	target := fs.String("target", envutils.GetenvOrDefault("REPLAY_TARGET", defTarget), "target url")
+	fs.Var(&headers, "H", `override header, "Name: value" (repeatable, empty value removes header)`)
+	concurrency := fs.Int("concurrency", defConcurrency, "number of concurrent requests")
+	rate := fs.Float64("rate", 0, "max requests per second, 0 is unlimited")
+	timeout := fs.Duration("timeout", defTimeout, "request timeout")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+
+		return fmt.Errorf("replay flag error: %w", err)
+	}
+
+	targetURL, err := url.Parse(*target)
+	if err != nil || targetURL.Scheme == "" || targetURL.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidTarget, *target)
+	}
+
+	files, err := CollectFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	opts := Options{
+		Target:      targetURL,
+		Headers:     headers.header,
+		Client:      &http.Client{Timeout: *timeout},
+		Concurrency: *concurrency,
+		Rate:        *rate,
+	}
+
+	summary := Replay(ctx, files, opts, func(r Result) {
+		if r.Err != nil {
+			fmt.Fprintf(out, "%-4s %10s  %s: %v\n", "ERR", "-", r.File, r.Err)
+
+			return
+		}
+		fmt.Fprintf(out, "%-4d %10s  %s\n", r.StatusCode, r.Latency.Round(time.Microsecond), r.File)
+	})
+
+	fmt.Fprintf(out, "\nfiles: %d, succeeded: %d, failed: %d\n", summary.Total, summary.Succeeded, summary.Failed)
+	fmt.Fprintf(out, "latency min/avg/max: %s/%s/%s\n",
+		summary.MinLatency.Round(time.Microsecond),
+		summary.AvgLatency.Round(time.Microsecond),
+		summary.MaxLatency.Round(time.Microsecond),
+	)
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("%w: %d of %d requests", ErrReplayFailed, summary.Failed, summary.Total)
+	}
+	if summary.Total < len(files) {
+		return fmt.Errorf("%w: interrupted", ErrReplayFailed)
+	}
+
+	return nil
+}
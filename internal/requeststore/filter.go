@@ -0,0 +1,363 @@
+package requeststore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signature statuses.
+const (
+	SignatureValid   = "valid"
+	SignatureInvalid = "invalid"
+	SignatureNone    = "none"
+)
+
+// sentinel errors.
+var (
+	ErrInvalidFilter = errors.New("invalid filter")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// HeaderMatch matches requests having header Name. If Value is set, header
+// value must contain it (case-insensitive).
+type HeaderMatch struct {
+	Name  string
+	Value string
+}
+
+// JSONMatch matches requests with a JSON body having a value at Path, e.g.
+// "$.pull_request.labels[0].name". If Value is set, the value must equal it;
+// strings are compared unquoted, other values as JSON.
+type JSONMatch struct {
+	Path  string
+	Value *string
+}
+
+// Filter selects requests, zero value matches all requests.
+type Filter struct {
+	Methods   []string
+	PathGlob  string
+	PathRegex *regexp.Regexp
+	Headers   []HeaderMatch
+	Body      string // substring of body
+	JSON      []JSONMatch
+	Since     time.Time
+	Until     time.Time
+	Signature string // one of SignatureValid, SignatureInvalid, SignatureNone
+	Direction string // "captured" or DirectionOutbound
+	Pinned    *bool
+	Tags      []string // all must be present, case-insensitive
+}
+
+// DirectionCaptured selects requests received by the debug server.
+const DirectionCaptured = "captured"
+
+// ParseFilter builds a filter from query parameters: method (comma
+// separated or repeated), path (glob), pathRegex, header ("Name" or
+// "Name: value", repeated), body, json ("$.path" or "$.path=value",
+// repeated), since, until (RFC 3339), signature, direction, pinned and tag
+// (comma separated or repeated).
+func ParseFilter(query url.Values) (Filter, error) {
+	var f Filter
+
+	for _, value := range query["method"] {
+		for method := range strings.SplitSeq(value, ",") {
+			if method = strings.TrimSpace(method); method != "" {
+				f.Methods = append(f.Methods, strings.ToUpper(method))
+			}
+		}
+	}
+
+	f.PathGlob = query.Get("path")
+	if _, err := path.Match(f.PathGlob, ""); err != nil {
+		return f, fmt.Errorf("%w: path: %w", ErrInvalidFilter, err)
+	}
+
+	if expr := query.Get("pathRegex"); expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return f, fmt.Errorf("%w: pathRegex: %w", ErrInvalidFilter, err)
+		}
+		f.PathRegex = re
+	}
+
+	for _, value := range query["header"] {
+		name, val, _ := strings.Cut(value, ":")
+		if name = strings.TrimSpace(name); name == "" {
+			return f, fmt.Errorf("%w: header: %q", ErrInvalidFilter, value)
+		}
+		f.Headers = append(f.Headers, HeaderMatch{Name: name, Value: strings.TrimSpace(val)})
+	}
+
+	f.Body = query.Get("body")
+
+	for _, value := range query["json"] {
+		jsonPath, val, hasValue := strings.Cut(value, "=")
+		if _, err := parseJSONPath(jsonPath); err != nil {
+			return f, fmt.Errorf("%w: json: %w", ErrInvalidFilter, err)
+		}
+
+		match := JSONMatch{Path: jsonPath}
+		if hasValue {
+			match.Value = &val
+		}
+		f.JSON = append(f.JSON, match)
+	}
+
+	var err error
+	if f.Since, err = parseTime(query.Get("since")); err != nil {
+		return f, fmt.Errorf("%w: since: %w", ErrInvalidFilter, err)
+	}
+	if f.Until, err = parseTime(query.Get("until")); err != nil {
+		return f, fmt.Errorf("%w: until: %w", ErrInvalidFilter, err)
+	}
+
+	f.Signature = query.Get("signature")
+	if f.Signature != "" && !slices.Contains([]string{SignatureValid, SignatureInvalid, SignatureNone}, f.Signature) {
+		return f, fmt.Errorf("%w: signature: %q", ErrInvalidFilter, f.Signature)
+	}
+
+	f.Direction = query.Get("direction")
+	if f.Direction != "" && f.Direction != DirectionCaptured && f.Direction != DirectionOutbound {
+		return f, fmt.Errorf("%w: direction: %q", ErrInvalidFilter, f.Direction)
+	}
+
+	if value := query.Get("pinned"); value != "" {
+		pinned, errPinned := strconv.ParseBool(value)
+		if errPinned != nil {
+			return f, fmt.Errorf("%w: pinned: %q", ErrInvalidFilter, value)
+		}
+		f.Pinned = &pinned
+	}
+
+	for _, value := range query["tag"] {
+		f.Tags = append(f.Tags, NormalizeTags(strings.Split(value, ","))...)
+	}
+
+	return f, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("time parse error: %w", err)
+	}
+
+	return t, nil
+}
+
+// IsZero reports whether the filter matches all requests.
+func (f Filter) IsZero() bool {
+	return len(f.Methods) == 0 && f.PathGlob == "" && f.PathRegex == nil && len(f.Headers) == 0 &&
+		f.Body == "" && len(f.JSON) == 0 && f.Since.IsZero() && f.Until.IsZero() &&
+		f.Signature == "" && f.Direction == "" && f.Pinned == nil && len(f.Tags) == 0
+}
+
+// Match reports whether req matches all conditions of the filter.
+func (f Filter) Match(req Request) bool {
+	if len(f.Methods) > 0 && !slices.Contains(f.Methods, req.Method) {
+		return false
+	}
+
+	if f.PathGlob != "" || f.PathRegex != nil {
+		reqPath := req.URL
+		if u, err := url.Parse(req.URL); err == nil {
+			reqPath = u.Path
+		}
+		if ok, _ := path.Match(f.PathGlob, reqPath); f.PathGlob != "" && !ok {
+			return false
+		}
+		if f.PathRegex != nil && !f.PathRegex.MatchString(reqPath) {
+			return false
+		}
+	}
+
+	for _, h := range f.Headers {
+		value, ok := headerValue(req.Headers, h.Name)
+		if !ok || !strings.Contains(strings.ToLower(value), strings.ToLower(h.Value)) {
+			return false
+		}
+	}
+
+	if f.Body != "" && !strings.Contains(req.Body, f.Body) && !strings.Contains(req.BodyText, f.Body) {
+		return false
+	}
+
+	if len(f.JSON) > 0 && !f.matchJSON(req.DecodedBody()) {
+		return false
+	}
+
+	if !f.Since.IsZero() && req.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && req.Time.After(f.Until) {
+		return false
+	}
+
+	if f.Signature != "" && signatureStatus(req) != f.Signature {
+		return false
+	}
+
+	if f.Pinned != nil && req.Pinned != *f.Pinned {
+		return false
+	}
+
+	for _, tag := range f.Tags {
+		if !slices.ContainsFunc(req.Tags, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return false
+		}
+	}
+
+	switch f.Direction {
+	case DirectionCaptured:
+		return req.Direction == ""
+	case DirectionOutbound:
+		return req.Direction == DirectionOutbound
+	}
+
+	return true
+}
+
+func (f Filter) matchJSON(body string) bool {
+	var doc any
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return false
+	}
+
+	for _, m := range f.JSON {
+		segments, _ := parseJSONPath(m.Path)
+		value, ok := lookupJSON(doc, segments)
+		if !ok {
+			return false
+		}
+		if m.Value != nil && formatJSONValue(value) != *m.Value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func headerValue(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// signatureStatus combines secret token, HMAC and JWT signature checks of
+// req.
+func signatureStatus(req Request) string {
+	var checks []bool
+	if req.Signature != nil {
+		if req.Signature.SecretToken != nil {
+			checks = append(checks, *req.Signature.SecretToken)
+		}
+		if req.Signature.HMAC != nil {
+			checks = append(checks, *req.Signature.HMAC)
+		}
+	}
+	if req.Authorization != nil && req.Authorization.JWT != nil && req.Authorization.JWT.Verified != nil {
+		checks = append(checks, *req.Authorization.JWT.Verified)
+	}
+
+	switch {
+	case len(checks) == 0:
+		return SignatureNone
+	case slices.Contains(checks, false):
+		return SignatureInvalid
+	default:
+		return SignatureValid
+	}
+}
+
+// parseJSONPath splits a path like "$.a.b[0]" or "a.b" into object keys
+// (string) and array indexes (int).
+func parseJSONPath(p string) ([]any, error) {
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+
+	var segments []any
+	for p != "" {
+		switch {
+		case strings.HasPrefix(p, `["`):
+			end := strings.Index(p, `"]`)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated key in %q", p)
+			}
+			segments = append(segments, p[2:end])
+			p = p[end+2:]
+		case strings.HasPrefix(p, "["):
+			end := strings.Index(p, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated index in %q", p)
+			}
+			index, err := strconv.Atoi(p[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index in %q", p)
+			}
+			segments = append(segments, index)
+			p = p[end+1:]
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in %q", p)
+			}
+			segments = append(segments, p[:end])
+			p = p[end:]
+		}
+		p = strings.TrimPrefix(p, ".")
+	}
+
+	return segments, nil
+}
+
+func lookupJSON(doc any, segments []any) (any, bool) {
+	for _, segment := range segments {
+		switch key := segment.(type) {
+		case string:
+			obj, ok := doc.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = obj[key]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := doc.([]any)
+			if !ok || key >= len(arr) {
+				return nil, false
+			}
+			doc = arr[key]
+		}
+	}
+
+	return doc, true
+}
+
+func formatJSONValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	data, _ := json.Marshal(value)
+
+	return string(data)
+}
@@ -0,0 +1,203 @@
+package requeststore
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/internal/authorization"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func filterFixtures() []Request {
+	base := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	return []Request{
+		{
+			ID:      "push",
+			Time:    base,
+			Method:  "POST",
+			URL:     "/hooks/github?x=1",
+			Headers: map[string]string{"X-Github-Event": "push", "Content-Type": "application/json"},
+			Body:    `{"ref":"refs/heads/main","commits":[{"id":"a1"}],"size":2}`,
+			Signature: &Signature{
+				HMAC: boolPtr(true),
+			},
+		},
+		{
+			ID:      "pr",
+			Time:    base.Add(time.Minute),
+			Method:  "POST",
+			URL:     "/hooks/github",
+			Headers: map[string]string{"X-Github-Event": "pull_request"},
+			Body:    `{"action":"opened","pull_request":{"labels":[{"name":"bug"}]}}`,
+			Pinned:  true,
+			Tags:    []string{"Bug", "triage"},
+			Signature: &Signature{
+				SecretToken: boolPtr(true),
+				HMAC:        boolPtr(false),
+			},
+		},
+		{
+			ID:     "health",
+			Time:   base.Add(2 * time.Minute),
+			Method: "GET",
+			URL:    "/health",
+			Authorization: &authorization.Info{
+				JWT: &authorization.JWT{Verified: boolPtr(true)},
+			},
+		},
+		{
+			ID:        "sent",
+			Time:      base.Add(3 * time.Minute),
+			Method:    "PUT",
+			URL:       "/api/items/1",
+			Body:      "plain text",
+			Direction: DirectionOutbound,
+			Tags:      []string{"bug"},
+		},
+	}
+}
+
+func matchingIDs(t *testing.T, query string) []string {
+	t.Helper()
+
+	values, err := url.ParseQuery(query)
+	require.NoError(t, err)
+
+	f, err := ParseFilter(values)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, req := range filterFixtures() {
+		if f.Match(req) {
+			ids = append(ids, req.ID)
+		}
+	}
+
+	return ids
+}
+
+func TestFilter_Match(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"push", "pr", "health", "sent"}},
+		{"method=get,put", []string{"health", "sent"}},
+		{"method=POST&method=GET", []string{"push", "pr", "health"}},
+		{"path=/hooks/*", []string{"push", "pr"}},
+		{"pathRegex=^/api/items/[0-9]%2B$", []string{"sent"}},
+		{"header=X-Github-Event", []string{"push", "pr"}},
+		{"header=x-github-event:+PULL", []string{"pr"}},
+		{"body=refs/heads", []string{"push"}},
+		{"json=$.commits[0].id=a1", []string{"push"}},
+		{"json=$.size=2", []string{"push"}},
+		{`json=pull_request.labels[0]["name"]=bug`, []string{"pr"}},
+		{"json=$.action", []string{"pr"}},
+		{"since=2026-10-18T12:01:00Z&until=2026-10-18T12:02:00Z", []string{"pr", "health"}},
+		{"signature=valid", []string{"push", "health"}},
+		{"signature=invalid", []string{"pr"}},
+		{"signature=none", []string{"sent"}},
+		{"direction=outbound", []string{"sent"}},
+		{"direction=captured&method=GET", []string{"health"}},
+		{"pinned=true", []string{"pr"}},
+		{"pinned=false&method=POST", []string{"push"}},
+		{"tag=bug", []string{"pr", "sent"}},
+		{"tag=bug,TRIAGE", []string{"pr"}},
+		{"tag=bug&tag=release", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchingIDs(t, tt.query))
+		})
+	}
+}
+
+func TestFilter_Match_decodedBody(t *testing.T) {
+	f, err := ParseFilter(url.Values{"json": {"$.ad=Şenoğlu"}})
+	require.NoError(t, err)
+
+	req := Request{
+		Body:     "{\"ad\":\"\xDEeno\xF0lu\"}", // windows-1254
+		BodyText: `{"ad":"Şenoğlu"}`,
+	}
+	assert.True(t, f.Match(req))
+}
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(url.Values{})
+	require.NoError(t, err)
+	assert.True(t, f.IsZero())
+
+	for _, query := range []string{
+		"path=[",
+		"pathRegex=(",
+		"header=:value",
+		"json=$.a[x]",
+		"since=yesterday",
+		"signature=maybe",
+		"direction=sideways",
+		"pinned=maybe",
+	} {
+		values, _ := url.ParseQuery(query)
+		_, err := ParseFilter(values)
+		assert.ErrorIs(t, err, ErrInvalidFilter, query)
+	}
+}
+
+func TestStore_Find(t *testing.T) {
+	store := New(10)
+	for _, req := range filterFixtures() {
+		store.Add(req)
+	}
+
+	t.Run("paginates newest first", func(t *testing.T) {
+		page, err := store.Find(Filter{}, "", 3)
+		require.NoError(t, err)
+		assert.Equal(t, 4, page.Total)
+		assert.Equal(t, "pr", page.Next)
+		require.Len(t, page.Requests, 3)
+		assert.Equal(t, "sent", page.Requests[0].ID)
+
+		page, err = store.Find(Filter{}, page.Next, 3)
+		require.NoError(t, err)
+		assert.Empty(t, page.Next)
+		require.Len(t, page.Requests, 1)
+		assert.Equal(t, "push", page.Requests[0].ID)
+	})
+
+	t.Run("paginates filtered requests", func(t *testing.T) {
+		f := Filter{Methods: []string{"POST"}}
+
+		page, err := store.Find(f, "", 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, page.Total)
+		assert.Equal(t, []string{"pr"}, ids(page.Requests))
+
+		page, err = store.Find(f, page.Next, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"push"}, ids(page.Requests))
+		assert.Empty(t, page.Next)
+	})
+
+	t.Run("rejects unknown cursor", func(t *testing.T) {
+		_, err := store.Find(Filter{}, "missing", 1)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func ids(requests []Request) []string {
+	result := make([]string, 0, len(requests))
+	for _, req := range requests {
+		result = append(result, req.ID)
+	}
+
+	return result
+}
@@ -1,42 +1,135 @@
 package requeststore
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/vbyazilim/basichttpdebugger/internal/authorization"
+	"github.com/vbyazilim/basichttpdebugger/internal/charset"
+	"github.com/vbyazilim/basichttpdebugger/internal/cloudevents"
 )
 
 const defaultMaxSize = 50
 
-// FileAttachment represents an uploaded file with base64 encoded data.
+// DirectionOutbound marks requests sent from the dashboard, captured requests
+// have no direction.
+const DirectionOutbound = "outbound"
+
+// FileAttachment represents an uploaded file with base64 encoded data. Path
+// is set if the file is saved to disk.
 type FileAttachment struct {
 	FieldName   string `json:"fieldName"`
 	Filename    string `json:"filename"`
 	ContentType string `json:"contentType"`
 	Size        int    `json:"size"`
+	SHA256      string `json:"sha256,omitempty"`
+	Path        string `json:"path,omitempty"`
+	SaveError   string `json:"saveError,omitempty"`
 	Data        string `json:"data,omitempty"` // base64 encoded for images
 }
 
+// BodyInfo describes a captured request body. Large bodies are spooled to
+// disk, Request.Body holds only a preview of them.
+type BodyInfo struct {
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+	Spooled bool   `json:"spooled,omitempty"`
+	Path    string `json:"-"`
+}
+
+// Signature holds results of secret token and HMAC checks, fields are nil if
+// the check is not configured.
+type Signature struct {
+	SecretToken *bool `json:"secretToken,omitempty"`
+	HMAC        *bool `json:"hmac,omitempty"`
+}
+
+// Response is the upstream response of an outbound or replayed request, or
+// the response sent to a captured request. Body is base64 encoded if it is
+// not valid UTF-8.
+type Response struct {
+	Status     int               `json:"status"`
+	StatusText string            `json:"statusText"`
+	Proto      string            `json:"proto"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	Encoding   string            `json:"encoding,omitempty"`
+	Size       int64             `json:"size"` // size of returned body
+	Truncated  bool              `json:"truncated,omitempty"`
+	DurationMs float64           `json:"durationMs"`
+	Error      string            `json:"error,omitempty"` // set if request could not be sent
+}
+
 // Request represents a captured HTTP request.
 type Request struct {
-	ID      string            `json:"id"`
-	Time    time.Time         `json:"time"`
-	Method  string            `json:"method"`
-	URL     string            `json:"url"`
-	Headers map[string]string `json:"headers"`
-	Body    string            `json:"body"`
-	Host    string            `json:"host"`
-	Proto   string            `json:"proto"`
-	Files   []FileAttachment  `json:"files,omitempty"`
+	ID            string              `json:"id"`
+	Seq           uint64              `json:"seq"` // monotonic store sequence number, set by Add
+	Time          time.Time           `json:"time"`
+	Method        string              `json:"method"`
+	URL           string              `json:"url"`
+	Headers       map[string]string   `json:"headers"`
+	Body          string              `json:"body"`
+	BodyText      string              `json:"bodyText,omitempty"` // utf-8 transcoded body, if differs
+	Charset       *charset.Result     `json:"charset,omitempty"`
+	BodyInfo      *BodyInfo           `json:"bodyInfo,omitempty"`
+	Host          string              `json:"host"`
+	Proto         string              `json:"proto"`
+	Files         []FileAttachment    `json:"files,omitempty"`
+	CloudEvents   *cloudevents.Batch  `json:"cloudEvents,omitempty"`
+	Authorization *authorization.Info `json:"authorization,omitempty"`
+	Signature     *Signature          `json:"signature,omitempty"`
+	Direction     string              `json:"direction,omitempty"`
+	Target        string              `json:"target,omitempty"` // full url of outbound requests
+	Response      *Response           `json:"response,omitempty"`
+	Bin           string              `json:"bin,omitempty"`    // token of capturing bin
+	Pinned        bool                `json:"pinned,omitempty"` // pinned requests are not evicted
+	Tags          []string            `json:"tags,omitempty"`
+	Notes         string              `json:"notes,omitempty"`
+}
+
+// DecodedBody returns the body transcoded to utf-8, the body itself if it
+// needs no transcoding.
+func (r Request) DecodedBody() string {
+	if r.BodyText != "" {
+		return r.BodyText
+	}
+
+	return r.Body
+}
+
+// Annotation updates user annotations of a stored request, nil fields are
+// left unchanged.
+type Annotation struct {
+	Pinned *bool     `json:"pinned,omitempty"`
+	Tags   *[]string `json:"tags,omitempty"`
+	Notes  *string   `json:"notes,omitempty"`
+}
+
+// Removal announces requests deleted from the store. Cleared is set and IDs
+// is empty when the whole store is cleared.
+type Removal struct {
+	IDs     []string `json:"ids,omitempty"`
+	Cleared bool     `json:"cleared,omitempty"`
 }
 
 // Store holds captured requests in memory with pub/sub support for SSE.
 type Store struct {
-	mu        sync.RWMutex
-	requests  []Request
-	maxSize   int
-	listeners []chan Request
+	mu               sync.RWMutex
+	requests         []Request
+	index            map[string]int // request id to offset + position in requests
+	offset           int            // number of requests evicted from the front
+	maxSize          int
+	seq              uint64
+	evicted          uint64 // number of requests evicted because the store is full
+	listeners        []*listener[Request]
+	updateListeners  []*listener[Request]
+	removalListeners []*listener[Removal]
+	evictHandlers    []func(Request)
 }
 
 // New creates a new request store with the given max size.
@@ -47,36 +140,164 @@ func New(maxSize int) *Store {
 
 	return &Store{
 		requests:  make([]Request, 0, maxSize),
+		index:     make(map[string]int, maxSize),
 		maxSize:   maxSize,
-		listeners: make([]chan Request, 0),
+		listeners: make([]*listener[Request], 0),
+	}
+}
+
+// listener is a subscriber of new requests, updates or removals, dropped
+// counts values not delivered because ch was full.
+type listener[T any] struct {
+	ch      chan T
+	dropped atomic.Int64
+}
+
+// broadcast sends v to listeners without blocking, counting drops.
+func broadcast[T any](listeners []*listener[T], v T) {
+	for _, l := range listeners {
+		select {
+		case l.ch <- v:
+		default:
+			l.dropped.Add(1)
+		}
 	}
 }
 
-// Add adds a new request to the store and broadcasts to listeners.
-func (s *Store) Add(req Request) {
+// droppedOf returns and resets the drop count of ch in listeners.
+func droppedOf[T any](listeners []*listener[T], ch chan T) int {
+	for _, l := range listeners {
+		if l.ch == ch {
+			return int(l.dropped.Swap(0))
+		}
+	}
+
+	return 0
+}
+
+// unsubscribe returns listeners without ch.
+func unsubscribe[T any](listeners []*listener[T], ch chan T) []*listener[T] {
+	for i, l := range listeners {
+		if l.ch == ch {
+			return append(listeners[:i], listeners[i+1:]...)
+		}
+	}
+
+	return listeners
+}
+
+// position returns index of request with given id in s.requests, caller
+// must hold the lock.
+func (s *Store) position(id string) (int, bool) {
+	seq, ok := s.index[id]
+	if !ok {
+		return 0, false
+	}
+
+	return seq - s.offset, true
+}
+
+// removeAt removes request at position i, caller must hold the lock.
+func (s *Store) removeAt(i int) Request {
+	removed := s.requests[i]
+	delete(s.index, removed.ID)
+
+	if i == 0 {
+		s.requests = s.requests[1:]
+		s.offset++
+
+		return removed
+	}
+
+	s.requests = append(s.requests[:i], s.requests[i+1:]...)
+	for j := i; j < len(s.requests); j++ {
+		s.index[s.requests[j].ID] = s.offset + j
+	}
+
+	return removed
+}
+
+// evictable returns position of the oldest unpinned request, or the oldest
+// request if all are pinned. Caller must hold the lock.
+func (s *Store) evictable() int {
+	for i, req := range s.requests {
+		if !req.Pinned {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// Add adds a new request to the store with the next sequence number,
+// broadcasts to listeners and returns ID of the stored request. A stored
+// request with the same ID is replaced, evict handlers are called for it.
+func (s *Store) Add(req Request) string {
 	if req.ID == "" {
 		req.ID = uuid.New().String()
 	}
 
 	s.mu.Lock()
 
+	var evicted []Request
+	if i, ok := s.position(req.ID); ok {
+		evicted = append(evicted, s.removeAt(i))
+	}
+
 	if len(s.requests) >= s.maxSize {
-		s.requests = s.requests[1:]
+		evicted = append(evicted, s.removeAt(s.evictable()))
+		s.evicted++
 	}
 
+	s.seq++
+	req.Seq = s.seq
+	s.index[req.ID] = s.offset + len(s.requests)
 	s.requests = append(s.requests, req)
 
-	listeners := make([]chan Request, len(s.listeners))
-	copy(listeners, s.listeners)
+	listeners := slices.Clone(s.listeners)
+
+	evictHandlers := make([]func(Request), len(s.evictHandlers))
+	copy(evictHandlers, s.evictHandlers)
 
 	s.mu.Unlock()
 
-	for _, ch := range listeners {
-		select {
-		case ch <- req:
-		default:
+	for _, old := range evicted {
+		for _, fn := range evictHandlers {
+			fn(old)
 		}
 	}
+
+	broadcast(listeners, req)
+
+	return req.ID
+}
+
+// Seq returns the sequence number of the last added request.
+func (s *Store) Seq() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.seq
+}
+
+// Since returns stored requests added after sequence number seq, oldest
+// first.
+func (s *Store) Since(seq uint64) []Request {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, _ := slices.BinarySearchFunc(s.requests, seq+1, func(req Request, target uint64) int {
+		switch {
+		case req.Seq < target:
+			return -1
+		case req.Seq > target:
+			return 1
+		}
+
+		return 0
+	})
+
+	return slices.Clone(s.requests[i:])
 }
 
 // GetAll returns all stored requests, newest first.
@@ -92,6 +313,249 @@ func (s *Store) GetAll() []Request {
 	return result
 }
 
+// Page is a page of requests returned by Find.
+type Page struct {
+	Requests []Request
+	Next     string // cursor of the next page, empty on the last page
+	Total    int    // number of matching requests in the store
+}
+
+// Find returns requests matching f, newest first. Cursor is the ID of the
+// last request of the previous page, limit <= 0 returns all remaining
+// requests.
+func (s *Store) Find(f Filter, cursor string, limit int) (Page, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := len(s.requests) - 1
+	if cursor != "" {
+		i, ok := s.position(cursor)
+		if !ok {
+			return Page{}, fmt.Errorf("%w: %s", ErrInvalidCursor, cursor)
+		}
+		start = i - 1
+	}
+
+	page := Page{Requests: []Request{}}
+
+	for i := len(s.requests) - 1; i >= 0; i-- {
+		req := s.requests[i]
+		if !f.Match(req) {
+			continue
+		}
+
+		page.Total++
+		if i > start {
+			continue
+		}
+		if limit > 0 && len(page.Requests) == limit {
+			if page.Next == "" {
+				page.Next = page.Requests[limit-1].ID
+			}
+
+			continue
+		}
+		page.Requests = append(page.Requests, req)
+	}
+
+	return page, nil
+}
+
+// Get returns the request with given id.
+func (s *Store) Get(id string) (Request, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.position(id)
+	if !ok {
+		return Request{}, false
+	}
+
+	return s.requests[i], true
+}
+
+// Annotate applies given annotation to the request with given id, announces
+// the update and returns the updated request. Returns false if there is no
+// such request.
+func (s *Store) Annotate(id string, a Annotation) (Request, bool) {
+	s.mu.Lock()
+
+	i, ok := s.position(id)
+	if !ok {
+		s.mu.Unlock()
+
+		return Request{}, false
+	}
+
+	req := s.requests[i]
+	if a.Pinned != nil {
+		req.Pinned = *a.Pinned
+	}
+	if a.Tags != nil {
+		req.Tags = NormalizeTags(*a.Tags)
+	}
+	if a.Notes != nil {
+		req.Notes = *a.Notes
+	}
+	s.requests[i] = req
+
+	s.mu.Unlock()
+
+	s.updated(req)
+
+	return req, true
+}
+
+// SetResponse sets the upstream response of the request with given id,
+// announces the update and returns the updated request. Returns false if
+// there is no such request.
+func (s *Store) SetResponse(id string, resp *Response) (Request, bool) {
+	s.mu.Lock()
+
+	i, ok := s.position(id)
+	if !ok {
+		s.mu.Unlock()
+
+		return Request{}, false
+	}
+
+	s.requests[i].Response = resp
+	req := s.requests[i]
+
+	s.mu.Unlock()
+
+	s.updated(req)
+
+	return req, true
+}
+
+// updated announces an updated request to update listeners.
+func (s *Store) updated(req Request) {
+	s.mu.RLock()
+	listeners := slices.Clone(s.updateListeners)
+	s.mu.RUnlock()
+
+	broadcast(listeners, req)
+}
+
+// NormalizeTags trims tags and drops empty and duplicate (case-insensitive)
+// ones, keeping their order.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.ContainsFunc(normalized, func(t string) bool { return strings.EqualFold(t, tag) }) {
+			continue
+		}
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) == 0 {
+		return nil
+	}
+
+	return normalized
+}
+
+// Delete removes the request with given id, calls evict handlers and
+// announces the removal. Returns false if there is no such request.
+func (s *Store) Delete(id string) bool {
+	s.mu.Lock()
+
+	i, ok := s.position(id)
+	if !ok {
+		s.mu.Unlock()
+
+		return false
+	}
+	removed := s.removeAt(i)
+
+	s.mu.Unlock()
+
+	s.removed([]Request{removed}, Removal{IDs: []string{id}})
+
+	return true
+}
+
+// Clear removes all requests, calls evict handlers and announces the
+// removal. Returns the number of removed requests.
+func (s *Store) Clear() int {
+	s.mu.Lock()
+
+	removed := s.requests
+	s.requests = make([]Request, 0, s.maxSize)
+	s.index = make(map[string]int, s.maxSize)
+	s.offset = 0
+
+	s.mu.Unlock()
+
+	s.removed(removed, Removal{Cleared: true})
+
+	return len(removed)
+}
+
+func (s *Store) removed(requests []Request, removal Removal) {
+	s.mu.RLock()
+	evictHandlers := make([]func(Request), len(s.evictHandlers))
+	copy(evictHandlers, s.evictHandlers)
+	listeners := slices.Clone(s.removalListeners)
+	s.mu.RUnlock()
+
+	for _, req := range requests {
+		for _, fn := range evictHandlers {
+			fn(req)
+		}
+	}
+
+	broadcast(listeners, removal)
+}
+
+// OnEvict registers a handler called for each request removed from the store
+// to make room for a new one, deleted or cleared.
+func (s *Store) OnEvict(fn func(Request)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictHandlers = append(s.evictHandlers, fn)
+}
+
+// Stats is a summary of the store.
+type Stats struct {
+	Total     int            `json:"total"`
+	Max       int            `json:"max"`
+	Pinned    int            `json:"pinned"`
+	Outbound  int            `json:"outbound"`
+	Methods   map[string]int `json:"methods"`
+	Seq       uint64         `json:"seq"`     // sequence number of the last added request
+	Evicted   uint64         `json:"evicted"` // requests evicted because the store was full
+	Listeners int            `json:"listeners"`
+}
+
+// Stats returns a summary of stored requests.
+func (s *Store) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := Stats{
+		Total:     len(s.requests),
+		Max:       s.maxSize,
+		Methods:   make(map[string]int),
+		Seq:       s.seq,
+		Evicted:   s.evicted,
+		Listeners: len(s.listeners),
+	}
+	for _, req := range s.requests {
+		if req.Pinned {
+			stats.Pinned++
+		}
+		if req.Direction == DirectionOutbound {
+			stats.Outbound++
+		}
+		stats.Methods[req.Method]++
+	}
+
+	return stats
+}
+
 // Count returns the number of stored requests.
 func (s *Store) Count() int {
 	s.mu.RLock()
@@ -105,12 +569,21 @@ func (s *Store) Subscribe() chan Request {
 	ch := make(chan Request, 10)
 
 	s.mu.Lock()
-	s.listeners = append(s.listeners, ch)
+	s.listeners = append(s.listeners, &listener[Request]{ch: ch})
 	s.mu.Unlock()
 
 	return ch
 }
 
+// Dropped returns and resets the number of requests not delivered to ch
+// since the last call because ch was full.
+func (s *Store) Dropped(ch chan Request) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return droppedOf(s.listeners, ch)
+}
+
 // Unsubscribe removes a channel from the listeners list.
 // Note: We don't close the channel here to avoid race conditions with Add().
 // The channel will be garbage collected when both sender and receiver are done.
@@ -118,15 +591,63 @@ func (s *Store) Unsubscribe(ch chan Request) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for i, listener := range s.listeners {
-		if listener != ch {
-			continue
-		}
+	s.listeners = unsubscribe(s.listeners, ch)
+}
 
-		s.listeners = append(s.listeners[:i], s.listeners[i+1:]...)
+// SubscribeUpdates creates a new channel for receiving annotated requests.
+func (s *Store) SubscribeUpdates() chan Request {
+	ch := make(chan Request, 10)
 
-		break
-	}
+	s.mu.Lock()
+	s.updateListeners = append(s.updateListeners, &listener[Request]{ch: ch})
+	s.mu.Unlock()
+
+	return ch
+}
+
+// UnsubscribeUpdates removes a channel from the update listeners list.
+func (s *Store) UnsubscribeUpdates(ch chan Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateListeners = unsubscribe(s.updateListeners, ch)
+}
+
+// DroppedUpdates returns and resets the number of updates not delivered to
+// ch since the last call because ch was full.
+func (s *Store) DroppedUpdates(ch chan Request) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return droppedOf(s.updateListeners, ch)
+}
+
+// SubscribeRemovals creates a new channel for receiving removals.
+func (s *Store) SubscribeRemovals() chan Removal {
+	ch := make(chan Removal, 10)
+
+	s.mu.Lock()
+	s.removalListeners = append(s.removalListeners, &listener[Removal]{ch: ch})
+	s.mu.Unlock()
+
+	return ch
+}
+
+// UnsubscribeRemovals removes a channel from the removal listeners list.
+func (s *Store) UnsubscribeRemovals(ch chan Removal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removalListeners = unsubscribe(s.removalListeners, ch)
+}
+
+// DroppedRemovals returns and resets the number of removals not delivered to
+// ch since the last call because ch was full.
+func (s *Store) DroppedRemovals(ch chan Removal) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return droppedOf(s.removalListeners, ch)
 }
 
 // ListenerCount returns the number of active listeners.
@@ -105,6 +105,251 @@ func TestStore_GetAll(t *testing.T) {
 	})
 }
 
+func TestStore_Get(t *testing.T) {
+	t.Run("returns request by id", func(t *testing.T) {
+		store := New(10)
+		store.Add(Request{ID: "1", Method: "GET", URL: "/first"})
+		store.Add(Request{ID: "2", Method: "POST", URL: "/second"})
+
+		req, ok := store.Get("2")
+
+		assert.True(t, ok)
+		assert.Equal(t, "/second", req.URL)
+	})
+
+	t.Run("returns false for unknown id", func(t *testing.T) {
+		store := New(10)
+
+		_, ok := store.Get("unknown")
+
+		assert.False(t, ok)
+	})
+}
+
+func TestStore_index(t *testing.T) {
+	t.Run("finds requests after eviction and deletion", func(t *testing.T) {
+		store := New(3)
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			store.Add(Request{ID: id})
+		}
+		require.True(t, store.Delete("4"))
+
+		for _, id := range []string{"3", "5"} {
+			req, ok := store.Get(id)
+			require.True(t, ok, id)
+			assert.Equal(t, id, req.ID)
+		}
+		for _, id := range []string{"1", "2", "4"} {
+			_, ok := store.Get(id)
+			assert.False(t, ok, id)
+		}
+
+		store.Add(Request{ID: "6"})
+		store.Add(Request{ID: "7"})
+		assert.Equal(t, []string{"7", "6", "5"}, ids(store.GetAll()))
+
+		req, ok := store.Get("6")
+		require.True(t, ok)
+		assert.Equal(t, "6", req.ID)
+	})
+
+	t.Run("replaces request with same id", func(t *testing.T) {
+		store := New(3)
+		store.Add(Request{ID: "1", URL: "/old"})
+		store.Add(Request{ID: "2"})
+		store.Add(Request{ID: "1", URL: "/new"})
+
+		assert.Equal(t, []string{"1", "2"}, ids(store.GetAll()))
+		req, _ := store.Get("1")
+		assert.Equal(t, "/new", req.URL)
+	})
+}
+
+func TestStore_Annotate(t *testing.T) {
+	t.Run("updates annotations and announces update", func(t *testing.T) {
+		store := New(10)
+		store.Add(Request{ID: "1", Tags: []string{"old"}, Notes: "keep"})
+
+		ch := store.SubscribeUpdates()
+		defer store.UnsubscribeUpdates(ch)
+
+		tags := []string{" deploy ", "", "Deploy", "bug"}
+		updated, ok := store.Annotate("1", Annotation{Pinned: boolPtr(true), Tags: &tags})
+		require.True(t, ok)
+
+		assert.True(t, updated.Pinned)
+		assert.Equal(t, []string{"deploy", "bug"}, updated.Tags)
+		assert.Equal(t, "keep", updated.Notes)
+		assert.Equal(t, updated, <-ch)
+
+		stored, _ := store.Get("1")
+		assert.Equal(t, updated, stored)
+
+		notes := ""
+		empty := []string{}
+		updated, _ = store.Annotate("1", Annotation{Tags: &empty, Notes: &notes})
+		assert.True(t, updated.Pinned)
+		assert.Nil(t, updated.Tags)
+		assert.Empty(t, updated.Notes)
+	})
+
+	t.Run("returns false for unknown id", func(t *testing.T) {
+		store := New(10)
+
+		_, ok := store.Annotate("missing", Annotation{Pinned: boolPtr(true)})
+		assert.False(t, ok)
+	})
+
+	t.Run("skips pinned requests on eviction", func(t *testing.T) {
+		store := New(3)
+		store.Add(Request{ID: "1"})
+		store.Add(Request{ID: "2"})
+		store.Add(Request{ID: "3"})
+		store.Annotate("1", Annotation{Pinned: boolPtr(true)})
+
+		store.Add(Request{ID: "4"})
+		assert.Equal(t, []string{"4", "3", "1"}, ids(store.GetAll()))
+
+		store.Annotate("3", Annotation{Pinned: boolPtr(true)})
+		store.Annotate("4", Annotation{Pinned: boolPtr(true)})
+
+		// all pinned, oldest is evicted
+		store.Add(Request{ID: "5"})
+		assert.Equal(t, []string{"5", "4", "3"}, ids(store.GetAll()))
+
+		_, ok := store.Get("1")
+		assert.False(t, ok)
+	})
+}
+
+func TestStore_SetResponse(t *testing.T) {
+	store := New(10)
+	store.Add(Request{ID: "out", Direction: DirectionOutbound})
+	updates := store.SubscribeUpdates()
+
+	got, ok := store.SetResponse("out", &Response{Status: 201})
+	require.True(t, ok)
+	assert.Equal(t, 201, got.Response.Status)
+
+	select {
+	case req := <-updates:
+		assert.Equal(t, "out", req.ID)
+		assert.Equal(t, 201, req.Response.Status)
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for update")
+	}
+
+	stored, _ := store.Get("out")
+	assert.Equal(t, 201, stored.Response.Status)
+
+	_, ok = store.SetResponse("missing", &Response{})
+	assert.False(t, ok)
+}
+
+func TestStore_Stats(t *testing.T) {
+	store := New(3)
+	store.Add(Request{Method: "PUT"})
+	store.Add(Request{Method: "POST", Pinned: true})
+	store.Add(Request{Method: "POST"})
+	store.Add(Request{Method: "GET", Direction: DirectionOutbound})
+	store.Subscribe()
+
+	assert.Equal(t, Stats{
+		Total:     3,
+		Max:       3,
+		Pinned:    1,
+		Outbound:  1,
+		Methods:   map[string]int{"POST": 2, "GET": 1},
+		Seq:       4,
+		Evicted:   1,
+		Listeners: 1,
+	}, store.Stats())
+}
+
+func TestStore_Delete(t *testing.T) {
+	store := New(10)
+	store.Add(Request{ID: "1"})
+	store.Add(Request{ID: "2"})
+
+	var evicted []string
+	store.OnEvict(func(req Request) {
+		evicted = append(evicted, req.ID)
+	})
+	ch := store.SubscribeRemovals()
+	defer store.UnsubscribeRemovals(ch)
+
+	assert.True(t, store.Delete("1"))
+	assert.False(t, store.Delete("1"))
+
+	assert.Equal(t, []string{"1"}, evicted)
+	assert.Equal(t, Removal{IDs: []string{"1"}}, <-ch)
+	assert.Equal(t, 1, store.Count())
+}
+
+func TestStore_Clear(t *testing.T) {
+	store := New(10)
+	store.Add(Request{ID: "1"})
+	store.Add(Request{ID: "2"})
+
+	var evicted []string
+	store.OnEvict(func(req Request) {
+		evicted = append(evicted, req.ID)
+	})
+	ch := store.SubscribeRemovals()
+
+	assert.Equal(t, 2, store.Clear())
+	assert.Equal(t, []string{"1", "2"}, evicted)
+	assert.Equal(t, Removal{Cleared: true}, <-ch)
+	assert.Zero(t, store.Count())
+
+	_, ok := store.Get("1")
+	assert.False(t, ok)
+
+	store.Add(Request{ID: "3"})
+	req, ok := store.Get("3")
+	assert.True(t, ok)
+	assert.Equal(t, "3", req.ID)
+
+	store.UnsubscribeRemovals(ch)
+	assert.Empty(t, store.removalListeners)
+}
+
+func TestStore_OnEvict(t *testing.T) {
+	t.Run("calls handlers with evicted requests", func(t *testing.T) {
+		store := New(2)
+
+		var evicted []string
+		store.OnEvict(func(req Request) {
+			evicted = append(evicted, req.ID)
+		})
+
+		store.Add(Request{ID: "1"})
+		store.Add(Request{ID: "2"})
+		assert.Empty(t, evicted)
+
+		store.Add(Request{ID: "3"})
+		store.Add(Request{ID: "4"})
+		assert.Equal(t, []string{"1", "2"}, evicted)
+	})
+
+	t.Run("calls handlers with replaced requests", func(t *testing.T) {
+		store := New(2)
+
+		var evicted []Request
+		store.OnEvict(func(req Request) {
+			evicted = append(evicted, req)
+		})
+
+		store.Add(Request{ID: "1", Body: "old"})
+		store.Add(Request{ID: "1", Body: "new"})
+
+		require.Len(t, evicted, 1)
+		assert.Equal(t, "old", evicted[0].Body)
+		assert.Equal(t, 1, store.Count())
+		assert.Equal(t, uint64(0), store.Stats().Evicted)
+	})
+}
+
 func TestStore_Subscribe(t *testing.T) {
 	t.Run("creates channel and adds to listeners", func(t *testing.T) {
 		store := New(10)
@@ -133,6 +378,69 @@ func TestStore_Subscribe(t *testing.T) {
 	})
 }
 
+func TestStore_Since(t *testing.T) {
+	store := New(3)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		store.Add(Request{ID: id})
+	}
+
+	assert.Equal(t, []string{"2", "3", "4"}, ids(store.Since(0)))
+	assert.Equal(t, []string{"3", "4"}, ids(store.Since(2)))
+	assert.Empty(t, store.Since(4))
+
+	store.Add(Request{ID: "3"})
+	got := store.Since(3)
+	assert.Equal(t, []string{"4", "3"}, ids(got))
+	assert.Equal(t, []uint64{4, 5}, []uint64{got[0].Seq, got[1].Seq})
+
+	store.Clear()
+	store.Add(Request{ID: "6"})
+	got = store.Since(5)
+	require.Len(t, got, 1)
+	assert.Equal(t, uint64(6), got[0].Seq)
+}
+
+func TestStore_Dropped(t *testing.T) {
+	store := New(50)
+	ch := store.Subscribe()
+
+	for range cap(ch) + 3 {
+		store.Add(Request{})
+	}
+
+	assert.Equal(t, 3, store.Dropped(ch))
+	assert.Equal(t, 0, store.Dropped(ch))
+	assert.Len(t, ch, cap(ch))
+
+	store.Unsubscribe(ch)
+	assert.Equal(t, 0, store.Dropped(ch))
+}
+
+func TestStore_DroppedUpdatesAndRemovals(t *testing.T) {
+	store := New(50)
+	updates := store.SubscribeUpdates()
+	removals := store.SubscribeRemovals()
+
+	id := store.Add(Request{})
+	for range cap(updates) + 2 {
+		_, ok := store.Annotate(id, Annotation{Tags: &[]string{"a"}})
+		require.True(t, ok)
+	}
+	for range cap(removals) + 4 {
+		store.Clear()
+	}
+
+	assert.Equal(t, 2, store.DroppedUpdates(updates))
+	assert.Equal(t, 0, store.DroppedUpdates(updates))
+	assert.Equal(t, 4, store.DroppedRemovals(removals))
+	assert.Equal(t, 0, store.DroppedRemovals(removals))
+
+	store.UnsubscribeUpdates(updates)
+	store.UnsubscribeRemovals(removals)
+	assert.Equal(t, 0, store.DroppedUpdates(updates))
+	assert.Equal(t, 0, store.DroppedRemovals(removals))
+}
+
 func TestStore_Unsubscribe(t *testing.T) {
 	t.Run("removes channel from listeners", func(t *testing.T) {
 		store := New(10)
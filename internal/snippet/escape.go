@@ -0,0 +1,117 @@
+package snippet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// shellQuote quotes s for POSIX shells using single quotes.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// printfEscape returns a printf format string producing b byte by byte.
+// Everything except printable ASCII is written as octal escape, so NUL
+// bytes and invalid UTF-8 survive the shell.
+func printfEscape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c >= ' ' && c <= '~' && c != '\\' && c != '%' && c != '\'' {
+			sb.WriteByte(c)
+
+			continue
+		}
+		fmt.Fprintf(&sb, `\%03o`, c)
+	}
+
+	return sb.String()
+}
+
+// pythonString returns a single quoted Python str literal.
+func pythonString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\'':
+			sb.WriteString(`\'`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if unicode.IsPrint(r) {
+				sb.WriteRune(r)
+			} else {
+				fmt.Fprintf(&sb, `\U%08x`, r)
+			}
+		}
+	}
+	sb.WriteByte('\'')
+
+	return sb.String()
+}
+
+// pythonBytes returns a Python bytes literal.
+func pythonBytes(b []byte) string {
+	var sb strings.Builder
+	sb.WriteString("b'")
+	for _, c := range b {
+		switch {
+		case c == '\\' || c == '\'':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c >= ' ' && c <= '~':
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, `\x%02x`, c)
+		}
+	}
+	sb.WriteByte('\'')
+
+	return sb.String()
+}
+
+// pythonBody returns body as Python expression. Text bodies are encoded to
+// utf-8 explicitly, requests would send str bodies as latin-1.
+func pythonBody(src source) string {
+	if !src.isText() {
+		return pythonBytes(src.body)
+	}
+
+	for _, c := range src.body {
+		if c > unicode.MaxASCII {
+			return pythonString(string(src.body)) + ".encode('utf-8')"
+		}
+	}
+
+	return pythonString(string(src.body))
+}
+
+// jsString returns a double quoted JavaScript string literal.
+func jsString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s) // encoding a string never fails
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// byteList returns b as comma separated decimal numbers.
+func byteList(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = strconv.Itoa(int(c))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,99 @@
+package snippet
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const (
+	httpFileSeparator = "###"
+	httpFileHostVar   = "host"
+	defHTTPVersion    = "HTTP/1.1"
+)
+
+// HTTPFile returns requests as a VS Code REST Client / JetBrains HTTP Client
+// file. Requests are separated with "###", hosts are extracted to variables.
+// Bodies that can not be written as text are read from "<id>.body" file.
+// Pin, tags and notes of requests are written as comments.
+func HTTPFile(requests []requeststore.Request) string {
+	var sb strings.Builder
+
+	hostVars := make(map[string]string)
+	for _, req := range requests {
+		if _, ok := hostVars[req.Host]; ok {
+			continue
+		}
+
+		name := httpFileHostVar
+		if len(hostVars) > 0 {
+			name += strconv.Itoa(len(hostVars) + 1)
+		}
+		hostVars[req.Host] = name
+		sb.WriteString("@" + name + " = " + req.Host + "\n")
+	}
+
+	for _, req := range requests {
+		sb.WriteString("\n" + httpFileSeparator + " " + req.Method + " " + bin.Path(req.Bin, req.URL) + "\n")
+		writeHTTPFileAnnotations(&sb, req)
+		writeHTTPFileRequest(&sb, newSource(req), req, hostVars[req.Host])
+	}
+
+	return sb.String()
+}
+
+// NeedsBodyFile reports whether HTTPFile references body of req from
+// "<id>.body" file instead of writing it inline.
+func NeedsBodyFile(req requeststore.Request) bool {
+	src := newSource(req)
+
+	return src.bodyFile != "" || (src.hasBody() && !src.isText())
+}
+
+func writeHTTPFileAnnotations(sb *strings.Builder, req requeststore.Request) {
+	if req.Pinned {
+		sb.WriteString("# pinned\n")
+	}
+	if len(req.Tags) > 0 {
+		sb.WriteString("# tags: " + strings.Join(req.Tags, ", ") + "\n")
+	}
+	for line := range strings.Lines(req.Notes) {
+		sb.WriteString("# " + strings.TrimRight(line, "\r\n") + "\n")
+	}
+}
+
+func writeHTTPFileRequest(sb *strings.Builder, src source, req requeststore.Request, hostVar string) {
+	proto := req.Proto
+	if proto == "" {
+		proto = defHTTPVersion
+	}
+
+	target := "/"
+	if u, err := url.Parse(src.url); err == nil {
+		target = u.RequestURI()
+	}
+
+	sb.WriteString(src.method + " " + defScheme + "://{{" + hostVar + "}}" + target + " " + proto + "\n")
+	for _, h := range src.headers {
+		sb.WriteString(h[0] + ": " + h[1] + "\n")
+	}
+
+	if !src.hasBody() {
+		return
+	}
+
+	sb.WriteString("\n")
+	if NeedsBodyFile(req) {
+		sb.WriteString("< ./" + req.ID + ".body\n")
+
+		return
+	}
+
+	sb.WriteString(string(src.body))
+	if !strings.HasSuffix(string(src.body), "\n") {
+		sb.WriteString("\n")
+	}
+}
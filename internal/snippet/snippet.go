@@ -0,0 +1,302 @@
+package snippet
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+// supported formats.
+const (
+	FormatCurl       = "curl"
+	FormatHTTPie     = "httpie"
+	FormatGo         = "go"
+	FormatPython     = "python"
+	FormatJavaScript = "javascript"
+	FormatHTTPFile   = "http"
+)
+
+const (
+	defScheme    = "http"
+	shellNewline = " \\\n  "
+)
+
+// sentinel errors.
+var (
+	ErrUnknownFormat = errors.New("unknown snippet format")
+)
+
+// Formats lists supported snippet formats.
+var Formats = []string{FormatCurl, FormatHTTPie, FormatGo, FormatPython, FormatJavaScript, FormatHTTPFile}
+
+// headers that are computed by http clients and must not be copied.
+var skipHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Transfer-Encoding": true,
+}
+
+// source is the normalized input of generators.
+type source struct {
+	method   string
+	url      string
+	headers  [][2]string
+	body     []byte
+	bodyFile string // set if body is spooled to disk and must be read from file
+}
+
+func (s source) hasBody() bool {
+	return len(s.body) > 0 || s.bodyFile != ""
+}
+
+// isText reports whether inline body is printable UTF-8 text.
+func (s source) isText() bool {
+	if !utf8.Valid(s.body) {
+		return false
+	}
+	for _, r := range string(s.body) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Generate returns code reproducing req in given format. Spooled bodies are
+// too large to inline, snippets read them from "<id>.body" file which can be
+// downloaded from the dashboard.
+func Generate(req requeststore.Request, format string) (string, error) {
+	src := newSource(req)
+
+	switch format {
+	case FormatCurl:
+		return curl(src), nil
+	case FormatHTTPie:
+		return httpie(src), nil
+	case FormatGo:
+		return goNetHTTP(src), nil
+	case FormatPython:
+		return pythonRequests(src), nil
+	case FormatJavaScript:
+		return jsFetch(src), nil
+	case FormatHTTPFile:
+		return HTTPFile([]requeststore.Request{req}), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+func newSource(req requeststore.Request) source {
+	u := &url.URL{Scheme: defScheme, Host: req.Host}
+	if parsed, err := url.Parse(bin.Path(req.Bin, req.URL)); err == nil {
+		u.Path, u.RawPath, u.RawQuery = parsed.Path, parsed.RawPath, parsed.RawQuery
+	}
+
+	keys := make([]string, 0, len(req.Headers))
+	for key := range req.Headers {
+		if !skipHeaders[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	headers := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		headers = append(headers, [2]string{key, req.Headers[key]})
+	}
+
+	src := source{
+		method:  req.Method,
+		url:     u.String(),
+		headers: headers,
+		body:    []byte(req.Body),
+	}
+	if req.BodyInfo != nil && req.BodyInfo.Spooled {
+		src.body = nil
+		src.bodyFile = req.ID + ".body"
+	}
+
+	return src
+}
+
+func curl(src source) string {
+	var sb strings.Builder
+
+	binary := src.bodyFile == "" && src.hasBody() && !src.isText()
+	if binary {
+		sb.WriteString("printf " + shellQuote(printfEscape(src.body)) + " | ")
+	}
+
+	sb.WriteString("curl")
+	if src.method != "GET" || src.hasBody() {
+		sb.WriteString(" -X " + shellQuote(src.method))
+	}
+	sb.WriteString(" " + shellQuote(src.url))
+
+	for _, h := range src.headers {
+		sb.WriteString(shellNewline + "-H " + shellQuote(h[0]+": "+h[1]))
+	}
+
+	switch {
+	case src.bodyFile != "":
+		sb.WriteString(shellNewline + "--data-binary " + shellQuote("@"+src.bodyFile))
+	case binary:
+		sb.WriteString(shellNewline + "--data-binary @-")
+	case src.hasBody():
+		sb.WriteString(shellNewline + "--data-raw " + shellQuote(string(src.body)))
+	}
+
+	return sb.String() + "\n"
+}
+
+func httpie(src source) string {
+	var sb strings.Builder
+
+	binary := src.bodyFile == "" && src.hasBody() && !src.isText()
+	if binary {
+		sb.WriteString("printf " + shellQuote(printfEscape(src.body)) + " | ")
+	}
+
+	sb.WriteString("http")
+	if !src.hasBody() {
+		sb.WriteString(" --ignore-stdin")
+	}
+	if src.hasBody() && !binary && src.bodyFile == "" {
+		sb.WriteString(" --raw " + shellQuote(string(src.body)))
+	}
+	sb.WriteString(" " + shellQuote(src.method) + " " + shellQuote(src.url))
+
+	for _, h := range src.headers {
+		// "Name:" unsets a header in httpie, "Name;" sends it empty
+		if h[1] == "" {
+			sb.WriteString(shellNewline + shellQuote(h[0]+";"))
+
+			continue
+		}
+		sb.WriteString(shellNewline + shellQuote(h[0]+":"+h[1]))
+	}
+
+	if src.bodyFile != "" {
+		sb.WriteString(shellNewline + "< " + shellQuote(src.bodyFile))
+	}
+
+	return sb.String() + "\n"
+}
+
+func goNetHTTP(src source) string {
+	var sb strings.Builder
+
+	imports := []string{"fmt", "io", "net/http"}
+	bodyExpr := "nil"
+
+	switch {
+	case src.bodyFile != "":
+		imports = append(imports, "os")
+	case src.hasBody():
+		imports = append(imports, "strings")
+		bodyExpr = "strings.NewReader(" + strconv.Quote(string(src.body)) + ")"
+	}
+	sort.Strings(imports)
+
+	sb.WriteString("package main\n\nimport (\n")
+	for _, imp := range imports {
+		sb.WriteString("\t" + strconv.Quote(imp) + "\n")
+	}
+	sb.WriteString(")\n\nfunc main() {\n")
+
+	if src.bodyFile != "" {
+		sb.WriteString("\tbody, err := os.Open(" + strconv.Quote(src.bodyFile) + ")\n")
+		sb.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+		sb.WriteString("\tdefer body.Close()\n\n")
+		bodyExpr = "body"
+	}
+
+	sb.WriteString("\treq, err := http.NewRequest(" + strconv.Quote(src.method) + ", " +
+		strconv.Quote(src.url) + ", " + bodyExpr + ")\n")
+	sb.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	for _, h := range src.headers {
+		sb.WriteString("\treq.Header.Set(" + strconv.Quote(h[0]) + ", " + strconv.Quote(h[1]) + ")\n")
+	}
+
+	sb.WriteString("\n\tresp, err := http.DefaultClient.Do(req)\n")
+	sb.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	sb.WriteString("\tdefer resp.Body.Close()\n\n")
+	sb.WriteString("\trespBody, _ := io.ReadAll(resp.Body)\n")
+	sb.WriteString("\tfmt.Println(resp.Status)\n")
+	sb.WriteString("\tfmt.Println(string(respBody))\n")
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+func pythonRequests(src source) string {
+	var sb strings.Builder
+
+	sb.WriteString("import requests\n\n")
+	sb.WriteString("url = " + pythonString(src.url) + "\n")
+
+	sb.WriteString("headers = {\n")
+	for _, h := range src.headers {
+		sb.WriteString("    " + pythonString(h[0]) + ": " + pythonString(h[1]) + ",\n")
+	}
+	sb.WriteString("}\n")
+
+	dataArg := ""
+	switch {
+	case src.bodyFile != "":
+		sb.WriteString("\nwith open(" + pythonString(src.bodyFile) + ", 'rb') as f:\n")
+		sb.WriteString("    data = f.read()\n")
+		dataArg = ", data=data"
+	case src.hasBody():
+		sb.WriteString("data = " + pythonBody(src) + "\n")
+		dataArg = ", data=data"
+	}
+
+	sb.WriteString("\nresponse = requests.request(" + pythonString(src.method) + ", url, headers=headers" +
+		dataArg + ")\n")
+	sb.WriteString("print(response.status_code)\n")
+	sb.WriteString("print(response.text)\n")
+
+	return sb.String()
+}
+
+func jsFetch(src source) string {
+	var sb strings.Builder
+
+	if src.bodyFile != "" {
+		sb.WriteString("import { readFile } from 'node:fs/promises';\n\n")
+	}
+
+	sb.WriteString("const response = await fetch(" + jsString(src.url) + ", {\n")
+	sb.WriteString("  method: " + jsString(src.method) + ",\n")
+	sb.WriteString("  headers: {\n")
+	for _, h := range src.headers {
+		sb.WriteString("    " + jsString(h[0]) + ": " + jsString(h[1]) + ",\n")
+	}
+	sb.WriteString("  },\n")
+
+	switch {
+	case src.bodyFile != "":
+		sb.WriteString("  body: await readFile(" + jsString(src.bodyFile) + "),\n")
+	case src.hasBody() && src.isText():
+		sb.WriteString("  body: " + jsString(string(src.body)) + ",\n")
+	case src.hasBody():
+		sb.WriteString("  body: new Uint8Array([" + byteList(src.body) + "]),\n")
+	}
+
+	sb.WriteString("});\n\n")
+	sb.WriteString("console.log(response.status);\n")
+	sb.WriteString("console.log(await response.text());\n")
+
+	return sb.String()
+}
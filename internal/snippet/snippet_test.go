@@ -0,0 +1,258 @@
+package snippet
+
+import (
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+func jsonRequest() requeststore.Request {
+	return requeststore.Request{
+		ID:     "req-1",
+		Method: "POST",
+		URL:    "/hooks?event=push",
+		Host:   "localhost:9002",
+		Headers: map[string]string{
+			"Content-Type":   "application/json",
+			"Content-Length": "25",
+			"X-Note":         "it's \"quoted\"",
+		},
+		Body: "{\"msg\":\"it's şeker\"}\n",
+	}
+}
+
+func binaryRequest() requeststore.Request {
+	return requeststore.Request{
+		ID:      "req-2",
+		Method:  "PUT",
+		URL:     "/blob",
+		Host:    "localhost:9002",
+		Headers: map[string]string{"Content-Type": "application/octet-stream"},
+		Body:    string([]byte{0x00, 0xFF, '%', '\\', '\'', 'a'}),
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := Generate(jsonRequest(), "cobol")
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+
+	t.Run("all formats", func(t *testing.T) {
+		for _, format := range Formats {
+			code, err := Generate(jsonRequest(), format)
+			require.NoError(t, err, format)
+			assert.Contains(t, code, "localhost:9002", format)
+			assert.Contains(t, code, "/hooks?event=push", format)
+			assert.NotContains(t, code, "Content-Length", format)
+		}
+	})
+}
+
+func TestCurl(t *testing.T) {
+	t.Run("text body", func(t *testing.T) {
+		code, _ := Generate(jsonRequest(), FormatCurl)
+
+		assert.Equal(t, `curl -X 'POST' 'http://localhost:9002/hooks?event=push' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Note: it'\''s "quoted"' \
+  --data-raw '{"msg":"it'\''s şeker"}
+'
+`, code)
+	})
+
+	t.Run("binary body", func(t *testing.T) {
+		code, _ := Generate(binaryRequest(), FormatCurl)
+
+		assert.True(t, strings.HasPrefix(code, `printf '\000\377\045\134\047a' | curl -X 'PUT'`))
+		assert.Contains(t, code, "--data-binary @-")
+	})
+
+	t.Run("get without body", func(t *testing.T) {
+		code, _ := Generate(requeststore.Request{Method: "GET", URL: "/", Host: "example.com"}, FormatCurl)
+
+		assert.Equal(t, "curl 'http://example.com/'\n", code)
+	})
+
+	t.Run("bin request", func(t *testing.T) {
+		req := requeststore.Request{Method: "GET", URL: "/hooks?event=push", Host: "localhost:9002", Bin: "alice"}
+		code, _ := Generate(req, FormatCurl)
+
+		assert.Equal(t, "curl 'http://localhost:9002/b/alice/hooks?event=push'\n", code)
+	})
+
+	t.Run("spooled body", func(t *testing.T) {
+		req := binaryRequest()
+		req.BodyInfo = &requeststore.BodyInfo{Spooled: true}
+		code, _ := Generate(req, FormatCurl)
+
+		assert.Contains(t, code, "--data-binary '@req-2.body'")
+		assert.NotContains(t, code, "printf")
+	})
+
+	for _, req := range []requeststore.Request{jsonRequest(), binaryRequest()} {
+		t.Run("runs in shell "+req.ID, func(t *testing.T) {
+			if _, err := exec.LookPath("curl"); err != nil {
+				t.Skip("curl not found")
+			}
+
+			var gotMethod, gotNote string
+			var gotBody []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotMethod, gotNote = r.Method, r.Header.Get("X-Note")
+				gotBody, _ = io.ReadAll(r.Body)
+			}))
+			defer server.Close()
+
+			req.Host = strings.TrimPrefix(server.URL, "http://")
+			code, _ := Generate(req, FormatCurl)
+
+			out, err := exec.Command("sh", "-c", strings.Replace(code, "curl ", "curl -s ", 1)).CombinedOutput()
+			require.NoError(t, err, string(out))
+			assert.Equal(t, req.Method, gotMethod)
+			assert.Equal(t, req.Headers["X-Note"], gotNote)
+			assert.Equal(t, req.Body, string(gotBody))
+		})
+	}
+}
+
+func TestHTTPie(t *testing.T) {
+	code, _ := Generate(jsonRequest(), FormatHTTPie)
+	assert.True(t, strings.HasPrefix(code, `http --raw '{"msg":"it'\''s şeker`))
+	assert.Contains(t, code, `'POST' 'http://localhost:9002/hooks?event=push'`)
+	assert.Contains(t, code, `'X-Note:it'\''s "quoted"'`)
+
+	code, _ = Generate(binaryRequest(), FormatHTTPie)
+	assert.True(t, strings.HasPrefix(code, `printf '\000\377\045\134\047a' | http 'PUT'`))
+
+	code, _ = Generate(requeststore.Request{Method: "GET", URL: "/", Host: "example.com"}, FormatHTTPie)
+	assert.Equal(t, "http --ignore-stdin 'GET' 'http://example.com/'\n", code)
+
+	code, _ = Generate(requeststore.Request{
+		Method:  "GET",
+		URL:     "/",
+		Host:    "example.com",
+		Headers: map[string]string{"X-Empty": ""},
+	}, FormatHTTPie)
+	assert.Equal(t, "http --ignore-stdin 'GET' 'http://example.com/' \\\n  'X-Empty;'\n", code)
+}
+
+func TestGo(t *testing.T) {
+	for _, req := range []requeststore.Request{jsonRequest(), binaryRequest()} {
+		code, _ := Generate(req, FormatGo)
+
+		_, err := parser.ParseFile(token.NewFileSet(), "main.go", code, 0)
+		require.NoError(t, err, code)
+	}
+
+	code, _ := Generate(binaryRequest(), FormatGo)
+	assert.Contains(t, code, `strings.NewReader("\x00\xff%\\'a")`)
+
+	req := binaryRequest()
+	req.BodyInfo = &requeststore.BodyInfo{Spooled: true}
+	code, _ = Generate(req, FormatGo)
+	assert.Contains(t, code, `os.Open("req-2.body")`)
+
+	_, err := parser.ParseFile(token.NewFileSet(), "main.go", code, 0)
+	require.NoError(t, err, code)
+}
+
+func TestPython(t *testing.T) {
+	code, _ := Generate(jsonRequest(), FormatPython)
+	assert.Contains(t, code, `'X-Note': 'it\'s "quoted"',`)
+	assert.Contains(t, code, `data = '{"msg":"it\'s şeker"}\n'.encode('utf-8')`)
+	assert.Contains(t, code, `requests.request('POST', url, headers=headers, data=data)`)
+
+	code, _ = Generate(binaryRequest(), FormatPython)
+	assert.Contains(t, code, `data = b'\x00\xff%\\\'a'`)
+
+	code, _ = Generate(requeststore.Request{Method: "GET", URL: "/", Host: "example.com"}, FormatPython)
+	assert.Contains(t, code, `requests.request('GET', url, headers=headers)`)
+}
+
+func TestJavaScript(t *testing.T) {
+	code, _ := Generate(jsonRequest(), FormatJavaScript)
+	assert.Contains(t, code, `"X-Note": "it's \"quoted\"",`)
+	assert.Contains(t, code, `body: "{\"msg\":\"it's şeker\"}\n",`)
+
+	code, _ = Generate(binaryRequest(), FormatJavaScript)
+	assert.Contains(t, code, `body: new Uint8Array([0, 255, 37, 92, 39, 97]),`)
+
+	req := binaryRequest()
+	req.BodyInfo = &requeststore.BodyInfo{Spooled: true}
+	code, _ = Generate(req, FormatJavaScript)
+	assert.Contains(t, code, `body: await readFile("req-2.body"),`)
+}
+
+func TestHTTPFile(t *testing.T) {
+	t.Run("bundles requests with host variables", func(t *testing.T) {
+		other := requeststore.Request{ID: "req-3", Method: "GET", URL: "/status", Host: "example.com", Proto: "HTTP/2.0"}
+
+		code := HTTPFile([]requeststore.Request{jsonRequest(), other, binaryRequest()})
+
+		assert.Equal(t, `@host = localhost:9002
+@host2 = example.com
+
+### POST /hooks?event=push
+POST http://{{host}}/hooks?event=push HTTP/1.1
+Content-Type: application/json
+X-Note: it's "quoted"
+
+{"msg":"it's şeker"}
+
+### GET /status
+GET http://{{host2}}/status HTTP/2.0
+
+### PUT /blob
+PUT http://{{host}}/blob HTTP/1.1
+Content-Type: application/octet-stream
+
+< ./req-2.body
+`, code)
+	})
+
+	t.Run("writes annotations as comments", func(t *testing.T) {
+		req := requeststore.Request{
+			ID: "req-4", Method: "GET", URL: "/", Host: "localhost:9002",
+			Pinned: true, Tags: []string{"deploy", "bug"}, Notes: "first\nsecond",
+		}
+
+		assert.Equal(t, `@host = localhost:9002
+
+### GET /
+# pinned
+# tags: deploy, bug
+# first
+# second
+GET http://{{host}}/ HTTP/1.1
+`, HTTPFile([]requeststore.Request{req}))
+	})
+
+	t.Run("bin request", func(t *testing.T) {
+		req := requeststore.Request{ID: "req-5", Method: "GET", URL: "/hooks", Host: "localhost:9002", Bin: "alice"}
+
+		assert.Equal(t, `@host = localhost:9002
+
+### GET /b/alice/hooks
+GET http://{{host}}/b/alice/hooks HTTP/1.1
+`, HTTPFile([]requeststore.Request{req}))
+	})
+
+	t.Run("needs body file", func(t *testing.T) {
+		assert.False(t, NeedsBodyFile(jsonRequest()))
+		assert.True(t, NeedsBodyFile(binaryRequest()))
+
+		req := jsonRequest()
+		req.BodyInfo = &requeststore.BodyInfo{Spooled: true}
+		assert.True(t, NeedsBodyFile(req))
+	})
+}
@@ -93,7 +93,8 @@ func FormatDate(format string, date *time.Time) string {
 	return replacer.Replace(format)
 }
 
-func sanitizeFilename(input string) string {
+// SanitizeFilename replaces characters that are unsafe in filenames with "_".
+func SanitizeFilename(input string) string {
 	return illegalChars().ReplaceAllString(input, "_")
 }
 
@@ -107,8 +108,8 @@ func GetFormattedFilename(s string, req *http.Request) string {
 	decoded, _ := url.QueryUnescape(u)
 
 	argReplacer := strings.NewReplacer(
-		"{hostname}", sanitizeFilename(req.Host),
-		"{url}", sanitizeFilename(decoded),
+		"{hostname}", SanitizeFilename(req.Host),
+		"{url}", SanitizeFilename(decoded),
 	)
 	sArgs := argReplacer.Replace(s)
 
@@ -240,3 +240,9 @@ func TestGetFormattedFilename_HomeErr(t *testing.T) {
 	result := stringutils.GetFormattedFilename(format, req)
 	assert.Equal(t, expected, result)
 }
+
+func TestSanitizeFilename(t *testing.T) {
+	assert.Equal(t, "my_report_v1.pdf", stringutils.SanitizeFilename("my report:v1.pdf"))
+	assert.Equal(t, "a_b_c.txt", stringutils.SanitizeFilename(`a/b\c.txt`))
+	assert.Equal(t, "plain.txt", stringutils.SanitizeFilename("plain.txt"))
+}
@@ -0,0 +1,245 @@
+package webui
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// roles of dashboard users.
+const (
+	RoleAdmin    = "admin"
+	RoleReadOnly = "read-only" // can view requests, can not replay, send, import or delete
+)
+
+const (
+	tokenCookieName  = "basichttpdebugger_token"
+	tokenQueryParam  = "access_token"
+	authRealm        = "basichttpdebugger"
+	corsAllowHeaders = "Authorization, Content-Type"
+	corsExposeHeader = "X-Total-Count, X-Next-Cursor"
+)
+
+// sentinel errors.
+var (
+	ErrInvalidCredential = errors.New("invalid credential, expected \"username:password\"")
+)
+
+// Credential grants Role to requests with given basic auth username and
+// password, or with given bearer token.
+type Credential struct {
+	Username string
+	Password string
+	Token    string
+	Role     string
+}
+
+// ParseBasicCredential parses a "username:password" credential.
+func ParseBasicCredential(s, role string) (Credential, error) {
+	username, password, ok := strings.Cut(s, ":")
+	if !ok || username == "" || password == "" {
+		return Credential{}, ErrInvalidCredential
+	}
+
+	return Credential{Username: username, Password: password, Role: role}, nil
+}
+
+// ParseCORSOrigins parses comma separated origins, e.g.
+// "https://a.example.com, http://localhost:3000" or "*".
+func ParseCORSOrigins(s string) ([]string, error) {
+	var origins []string
+	for origin := range strings.SplitSeq(s, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		if origin != "*" {
+			if err := http.NewCrossOriginProtection().AddTrustedOrigin(origin); err != nil {
+				return nil, fmt.Errorf("invalid cors origin %q: %w", origin, err)
+			}
+		}
+		origins = append(origins, origin)
+	}
+
+	return origins, nil
+}
+
+// WithCredentials enables authentication, all routes require one of given
+// credentials. Credentials without a role are admins.
+func WithCredentials(credentials ...Credential) Option {
+	return func(w *WebUI) {
+		for _, c := range credentials {
+			if c.Role == "" {
+				c.Role = RoleAdmin
+			}
+			w.credentials = append(w.credentials, c)
+		}
+	}
+}
+
+// WithCORSOrigins allows cross-origin requests from given origins, "*"
+// allows read requests from any origin. State changing requests are allowed
+// only from explicitly given origins.
+func WithCORSOrigins(origins ...string) Option {
+	return func(w *WebUI) {
+		w.corsOrigins = append(w.corsOrigins, origins...)
+	}
+}
+
+type roleContextKey struct{}
+
+// secure wraps next with CORS, authentication, authorization and CSRF
+// protection.
+func (w *WebUI) secure(next http.Handler) (http.Handler, error) {
+	csrf := http.NewCrossOriginProtection()
+	for _, origin := range w.corsOrigins {
+		if origin == "*" {
+			continue
+		}
+		if err := csrf.AddTrustedOrigin(origin); err != nil {
+			return nil, fmt.Errorf("invalid cors origin %q: %w", origin, err)
+		}
+	}
+	protected := csrf.Handler(next)
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if w.cors(rw, r) {
+			return
+		}
+
+		role, ok := w.authenticate(rw, r)
+		if !ok {
+			if w.hasBasicCredential() {
+				rw.Header().Set("WWW-Authenticate", `Basic realm="`+authRealm+`", charset="UTF-8"`)
+			} else {
+				rw.Header().Set("WWW-Authenticate", `Bearer realm="`+authRealm+`"`)
+			}
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		if role == RoleReadOnly && !isSafeMethod(r.Method) {
+			http.Error(rw, "forbidden, read-only access", http.StatusForbidden)
+
+			return
+		}
+
+		protected.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), roleContextKey{}, role)))
+	}), nil
+}
+
+// cors sets CORS headers for allowed origins and answers preflight requests,
+// returns true if the request is answered.
+func (w *WebUI) cors(rw http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(w.corsOrigins) == 0 {
+		return false
+	}
+
+	rw.Header().Add("Vary", "Origin")
+
+	switch {
+	case slices.Contains(w.corsOrigins, origin):
+		rw.Header().Set("Access-Control-Allow-Origin", origin)
+		rw.Header().Set("Access-Control-Allow-Credentials", "true")
+	case slices.Contains(w.corsOrigins, "*"):
+		rw.Header().Set("Access-Control-Allow-Origin", "*")
+	default:
+		return false
+	}
+	rw.Header().Set("Access-Control-Expose-Headers", corsExposeHeader)
+
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		return false
+	}
+
+	rw.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE")
+	rw.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+	rw.WriteHeader(http.StatusNoContent)
+
+	return true
+}
+
+// authenticate returns role of the request. Tokens are accepted in
+// Authorization header, in a cookie, or in "access_token" query parameter
+// which also sets the cookie for the dashboard and its event stream.
+func (w *WebUI) authenticate(rw http.ResponseWriter, r *http.Request) (string, bool) {
+	if len(w.credentials) == 0 {
+		return RoleAdmin, true
+	}
+
+	if username, password, ok := r.BasicAuth(); ok {
+		for _, c := range w.credentials {
+			if c.Username != "" && equal(c.Username, username) && equal(c.Password, password) {
+				return c.Role, true
+			}
+		}
+
+		return "", false
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return w.tokenRole(token)
+	}
+
+	if token := r.URL.Query().Get(tokenQueryParam); token != "" {
+		role, ok := w.tokenRole(token)
+		if ok {
+			http.SetCookie(rw, &http.Cookie{
+				Name:     tokenCookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+
+		return role, ok
+	}
+
+	if cookie, err := r.Cookie(tokenCookieName); err == nil {
+		return w.tokenRole(cookie.Value)
+	}
+
+	return "", false
+}
+
+func (w *WebUI) tokenRole(token string) (string, bool) {
+	for _, c := range w.credentials {
+		if c.Token != "" && equal(c.Token, token) {
+			return c.Role, true
+		}
+	}
+
+	return "", false
+}
+
+func (w *WebUI) hasBasicCredential() bool {
+	return slices.ContainsFunc(w.credentials, func(c Credential) bool { return c.Username != "" })
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// sessionHandler returns the role of the current user, the dashboard hides
+// actions of read-only users.
+func (*WebUI) sessionHandler(rw http.ResponseWriter, r *http.Request) {
+	role, _ := r.Context().Value(roleContextKey{}).(string)
+	if role == "" {
+		role = RoleAdmin
+	}
+
+	rw.Header().Set(headerContentType, contentTypeJSON)
+	_ = json.NewEncoder(rw).Encode(map[string]string{"role": role})
+}
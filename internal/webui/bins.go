@@ -0,0 +1,180 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const maxBinConfigSize = 1 << 20 // 1MB
+
+type binContextKey struct{}
+
+// binView is the api representation of a bin. Secrets are returned only
+// once, to the creator of the bin.
+type binView struct {
+	Token                 string        `json:"token"`
+	Name                  string        `json:"name,omitempty"`
+	SecretToken           string        `json:"secretToken,omitempty"`
+	SecretTokenHeaderName string        `json:"secretTokenHeaderName,omitempty"`
+	HMACSecret            string        `json:"hmacSecret,omitempty"`
+	HMACHeaderName        string        `json:"hmacHeaderName,omitempty"`
+	Response              *bin.Response `json:"response,omitempty"`
+	Created               time.Time     `json:"created"`
+
+	HasSecretToken bool   `json:"hasSecretToken"`
+	HasHMACSecret  bool   `json:"hasHMACSecret"`
+	URL            string `json:"url"`       // capture url
+	Dashboard      string `json:"dashboard"` // bin scoped dashboard path
+	Requests       int    `json:"requests"`
+}
+
+func (w *WebUI) newBinView(b *bin.Bin) binView {
+	return binView{
+		Token:                 b.Token,
+		Name:                  b.Name,
+		SecretTokenHeaderName: b.SecretTokenHeaderName,
+		HMACHeaderName:        b.HMACHeaderName,
+		Response:              b.Response,
+		Created:               b.Created,
+		HasSecretToken:        b.SecretToken != "",
+		HasHMACSecret:         b.HMACSecret != "",
+		URL:                   buildDebugURL(w.debugAddr, bin.Path(b.Token, "/")),
+		Dashboard:             w.prefix + "/bins/" + b.Token + "/",
+		Requests:              b.Store.Count(),
+	}
+}
+
+// binScope serves next with the store of the bin in path, e.g.
+// "/bins/{token}/api/requests" is "/api/requests" of bin {token}.
+func (w *WebUI) binScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if w.bins == nil {
+			http.NotFound(rw, r)
+
+			return
+		}
+
+		token := r.PathValue("token")
+		b, ok := w.bins.Get(token)
+		if !ok {
+			http.Error(rw, "bin not found", http.StatusNotFound)
+
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), binContextKey{}, b)
+		http.StripPrefix("/bins/"+token, next).ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// binOf returns the bin of a bin scoped request.
+func binOf(r *http.Request) *bin.Bin {
+	b, _ := r.Context().Value(binContextKey{}).(*bin.Bin)
+
+	return b
+}
+
+// storeOf returns the store of the bin of a bin scoped request, or the
+// default store.
+func (w *WebUI) storeOf(r *http.Request) *requeststore.Store {
+	if b := binOf(r); b != nil {
+		return b.Store
+	}
+
+	return w.store
+}
+
+// binsHandler lists (GET) or creates (POST, with a bin.Config body) bins.
+func (w *WebUI) binsHandler(rw http.ResponseWriter, r *http.Request) {
+	if w.bins == nil {
+		http.Error(rw, "bins are disabled", http.StatusNotFound)
+
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		views := []binView{}
+		for _, b := range w.bins.List() {
+			views = append(views, w.newBinView(b))
+		}
+
+		rw.Header().Set(headerContentType, contentTypeJSON)
+		_ = json.NewEncoder(rw).Encode(views)
+	case http.MethodPost:
+		var cfg bin.Config
+		if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxBinConfigSize)).Decode(&cfg); err != nil {
+			http.Error(rw, "invalid request body", http.StatusBadRequest)
+
+			return
+		}
+
+		b, err := w.bins.Create(cfg)
+		if err != nil {
+			status := http.StatusBadRequest
+			switch {
+			case errors.Is(err, bin.ErrBinExists):
+				status = http.StatusConflict
+			case errors.Is(err, bin.ErrTooManyBins):
+				status = http.StatusInsufficientStorage
+			}
+			http.Error(rw, err.Error(), status)
+
+			return
+		}
+
+		view := w.newBinView(b)
+		view.SecretToken, view.HMACSecret = b.SecretToken, b.HMACSecret
+
+		rw.Header().Set(headerContentType, contentTypeJSON)
+		rw.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(rw).Encode(view)
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// binHandler returns (GET) or deletes (DELETE) a bin with its requests.
+func (w *WebUI) binHandler(rw http.ResponseWriter, r *http.Request) {
+	if w.bins == nil {
+		http.Error(rw, "bins are disabled", http.StatusNotFound)
+
+		return
+	}
+
+	token := r.PathValue("token")
+
+	switch r.Method {
+	case http.MethodGet:
+		b, ok := w.bins.Get(token)
+		if !ok {
+			http.Error(rw, "bin not found", http.StatusNotFound)
+
+			return
+		}
+
+		rw.Header().Set(headerContentType, contentTypeJSON)
+		_ = json.NewEncoder(rw).Encode(w.newBinView(b))
+	case http.MethodDelete:
+		b, ok := w.bins.Get(token)
+		if !ok || !w.bins.Delete(token) {
+			http.Error(rw, "bin not found", http.StatusNotFound)
+
+			return
+		}
+
+		w.expectationsMu.Lock()
+		delete(w.expectations, b.Store)
+		w.expectationsMu.Unlock()
+
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,53 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/diff"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+// diffHandler compares requests given with "left" and "right" query
+// parameters. Fields to ignore are given with comma separated or repeated
+// "ignore" parameters, "volatile=true" ignores common volatile fields too.
+func (w *WebUI) diffHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	query := r.URL.Query()
+
+	var requests [2]requeststore.Request
+	for i, param := range []string{"left", "right"} {
+		id := query.Get(param)
+		found, ok := w.storeOf(r).Get(id)
+		if !ok {
+			http.Error(rw, "request not found: "+id, http.StatusNotFound)
+
+			return
+		}
+		requests[i] = found
+	}
+
+	var ignore []string
+	for _, value := range query["ignore"] {
+		for field := range strings.SplitSeq(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				ignore = append(ignore, field)
+			}
+		}
+	}
+	if volatile, _ := strconv.ParseBool(query.Get("volatile")); volatile {
+		ignore = append(ignore, diff.Volatile...)
+	}
+
+	result := diff.Compare(requests[0], requests[1], diff.Options{Ignore: ignore})
+
+	rw.Header().Set(headerContentType, contentTypeJSON)
+	_ = json.NewEncoder(rw).Encode(result)
+}
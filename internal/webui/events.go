@@ -0,0 +1,237 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+// EventVersion is the version of the /events envelope, it changes only on
+// incompatible changes.
+const EventVersion = 1
+
+// event types of the /events stream.
+const (
+	EventRequest        = "request"         // new request, data is the request
+	EventRequestUpdated = "request-updated" // annotated or enriched request, data is the request
+	EventDeleted        = "deleted"         // data is requeststore.Removal
+	EventCleared        = "cleared"         // data is requeststore.Removal
+	EventDropped        = "dropped"         // data is {"count": n}, missed requests are replayed
+	EventResync         = "resync"          // data is {"count": n}, updates or removals were missed
+	EventStats          = "stats"           // data is requeststore.Stats
+)
+
+const statsDelay = 250 * time.Millisecond
+
+// Event is the envelope of /events stream events, sent with Type as the SSE
+// event name.
+type Event struct {
+	Version int       `json:"version"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Data    any       `json:"data"`
+}
+
+// writeEvent writes an event with data, id is set if not empty.
+func writeEvent(rw io.Writer, eventType, id string, data any) {
+	payload, err := json.Marshal(Event{
+		Version: EventVersion,
+		Type:    eventType,
+		Time:    time.Now().UTC(),
+		Data:    data,
+	})
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(rw, "event: %s\n", eventType)
+	if id != "" {
+		fmt.Fprintf(rw, "id: %s\n", id)
+	}
+	fmt.Fprintf(rw, "data: %s\n\n", payload)
+}
+
+// eventsHandler streams store events, requests are filtered with
+// requeststore.ParseFilter query parameters if given. Request events carry
+// the store sequence number as id, requests after the sequence number in
+// Last-Event-ID header (or "lastEventId" query parameter) are replayed from
+// the store first. Requests dropped because the client is too slow are
+// announced with a dropped event and replayed from the store, dropped updates
+// and removals are announced with a resync event, the client reloads the
+// requests then. Request and update events carry the stored version of
+// requests and are not sent for removed ones, requests streamed before a
+// clear that are still stored, i.e. added after it, are sent again after the
+// cleared event. Stats events are sent on connect and shortly after changes.
+func (w *WebUI) eventsHandler(rw http.ResponseWriter, r *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+
+		return
+	}
+
+	filter, err := requeststore.ParseFilter(r.URL.Query())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	store := w.storeOf(r)
+
+	lastSeq, err := lastEventID(r, store)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+
+	// streams outlive server write timeouts, e.g. the debug server one in
+	// single port mode
+	_ = http.NewResponseController(rw).SetWriteDeadline(time.Time{})
+
+	w.streams.Add(1)
+	defer w.streams.Add(-1)
+
+	ch := store.Subscribe()
+	defer store.Unsubscribe(ch)
+
+	updates := store.SubscribeUpdates()
+	defer store.UnsubscribeUpdates(updates)
+
+	removals := store.SubscribeRemovals()
+	defer store.UnsubscribeRemovals(removals)
+
+	heartbeat := time.NewTicker(w.heartbeat)
+	defer heartbeat.Stop()
+
+	// Send initial comment to establish connection
+	fmt.Fprint(rw, ": connected\n\n")
+
+	// requests added before subscribing, already sent ones are skipped below
+	writeRequestEvents(rw, filter, store.Since(lastSeq), &lastSeq)
+	writeEvent(rw, EventStats, "", store.Stats())
+	flusher.Flush()
+
+	// stats is set while a stats event is due, bursts of changes are
+	// announced with a single stats event
+	var stats <-chan time.Time
+	changed := func() {
+		if stats == nil {
+			stats = time.After(statsDelay)
+		}
+	}
+	resync := func() {
+		if missed := store.DroppedUpdates(updates) + store.DroppedRemovals(removals); missed > 0 {
+			writeEvent(rw, EventResync, "", map[string]int{"count": missed})
+		}
+	}
+
+	for {
+		select {
+		case <-ch:
+			if dropped := store.Dropped(ch); dropped > 0 {
+				writeEvent(rw, EventDropped, "", map[string]int{"count": dropped})
+			}
+			// stored requests only, the received one may be removed already
+			writeRequestEvents(rw, filter, store.Since(lastSeq), &lastSeq)
+			flusher.Flush()
+			changed()
+		case update := <-updates:
+			changed()
+			resync()
+			if update.Seq > lastSeq {
+				// update of a request not streamed yet, stream it first
+				writeRequestEvents(rw, filter, store.Since(lastSeq), &lastSeq)
+			}
+
+			// the stored version, updates may arrive out of order
+			req, ok := store.Get(update.ID)
+			if ok && req.Seq <= lastSeq && filter.Match(req) {
+				writeEvent(rw, EventRequestUpdated, "", req)
+			}
+			flusher.Flush()
+		case removal := <-removals:
+			resync()
+
+			eventType := EventDeleted
+			if removal.Cleared {
+				eventType = EventCleared
+			}
+
+			writeEvent(rw, eventType, "", removal)
+			if removal.Cleared {
+				// requests added after the clear but streamed before it
+				for _, req := range store.Since(0) {
+					if req.Seq <= lastSeq && filter.Match(req) {
+						writeEvent(rw, EventRequest, "", req)
+					}
+				}
+			}
+			flusher.Flush()
+			changed()
+		case <-stats:
+			stats = nil
+			writeEvent(rw, EventStats, "", store.Stats())
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(rw, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// lastEventID returns the sequence number the client has seen, the current
+// sequence number of store if the client has not sent one. Numbers ahead of
+// the store are from before a restart, all stored requests are new to the
+// client then.
+func lastEventID(r *http.Request, store *requeststore.Store) (uint64, error) {
+	id := r.Header.Get("Last-Event-ID")
+	if id == "" {
+		if !r.URL.Query().Has("lastEventId") {
+			return store.Seq(), nil
+		}
+		id = r.URL.Query().Get("lastEventId")
+	}
+
+	seq, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid last event id %q", id)
+	}
+	if seq > store.Seq() {
+		return 0, nil
+	}
+
+	return seq, nil
+}
+
+// writeRequestEvents writes requests newer than lastSeq and matching filter
+// as request events, lastSeq is advanced to the newest written or skipped
+// request.
+func writeRequestEvents(rw io.Writer, filter requeststore.Filter, requests []requeststore.Request, lastSeq *uint64) {
+	for _, req := range requests {
+		if req.Seq <= *lastSeq {
+			continue
+		}
+		*lastSeq = req.Seq
+
+		if !filter.Match(req) {
+			continue
+		}
+
+		writeEvent(rw, EventRequest, strconv.FormatUint(req.Seq, 10), req)
+	}
+}
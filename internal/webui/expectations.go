@@ -0,0 +1,237 @@
+package webui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const maxExpectationsSize = 1 << 20 // 1MB
+
+// expectation statuses.
+const (
+	expectationMet    = "met"
+	expectationMissed = "missed"
+)
+
+// expectation is an expected request registered with /api/expectations, it
+// is met by Count (default 1) matching requests stored after registration.
+type expectation struct {
+	Name  string      `json:"name,omitempty"`
+	Match matchValues `json:"match"` // requeststore.ParseFilter parameters
+	Count int         `json:"count"`
+	After uint64      `json:"after"` // store sequence number at registration
+
+	filter requeststore.Filter
+}
+
+// expectationResult is the state of an expectation.
+type expectationResult struct {
+	expectation
+
+	Status  string   `json:"status"`
+	Matched []string `json:"matched"` // ids of matching requests, oldest first
+}
+
+// expectationReport reports registered expectations of a store.
+type expectationReport struct {
+	Met          bool                `json:"met"` // all expectations are met
+	Expectations []expectationResult `json:"expectations"`
+	Unexpected   []string            `json:"unexpected"` // ids of captured requests matching no expectation
+}
+
+// expectationsHandler registers (POST, an expectation or an array of them),
+// reports (GET) or removes (DELETE) expected requests. GET with "wait"
+// duration blocks until all expectations are met or wait expires.
+func (w *WebUI) expectationsHandler(rw http.ResponseWriter, r *http.Request) {
+	store := w.storeOf(r)
+
+	switch r.Method {
+	case http.MethodGet:
+		w.reportExpectations(rw, r, store)
+	case http.MethodPost:
+		expectations, err := parseExpectations(http.MaxBytesReader(rw, r.Body, maxExpectationsSize))
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		after := store.Seq()
+		for _, e := range expectations {
+			e.After = after
+		}
+
+		w.expectationsMu.Lock()
+		w.expectations[store] = append(w.expectations[store], expectations...)
+		w.expectationsMu.Unlock()
+
+		rw.Header().Set(headerContentType, contentTypeJSON)
+		rw.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(rw).Encode(expectations)
+	case http.MethodDelete:
+		w.expectationsMu.Lock()
+		delete(w.expectations, store)
+		w.expectationsMu.Unlock()
+
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (w *WebUI) reportExpectations(rw http.ResponseWriter, r *http.Request, store *requeststore.Store) {
+	wait, err := parseWaitTimeout(r.URL.Query().Get("wait"), 0)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	ch := store.Subscribe()
+	defer store.Unsubscribe(ch)
+
+	report := w.expectationReport(store)
+	if wait > 0 && !report.Met {
+		ctx, cancel := w.waitContext(rw, r, wait)
+		defer cancel()
+
+	WAIT:
+		for !report.Met {
+			select {
+			case <-ch:
+				report = w.expectationReport(store)
+			case <-ctx.Done():
+				break WAIT
+			}
+		}
+	}
+
+	rw.Header().Set(headerContentType, contentTypeJSON)
+	_ = json.NewEncoder(rw).Encode(report)
+}
+
+// expectationReport matches requests stored after the oldest expectation of
+// store against its expectations. Requests evicted from the store are not
+// counted.
+func (w *WebUI) expectationReport(store *requeststore.Store) expectationReport {
+	w.expectationsMu.Lock()
+	expectations := slices.Clone(w.expectations[store])
+	w.expectationsMu.Unlock()
+
+	report := expectationReport{
+		Met:          true,
+		Expectations: make([]expectationResult, len(expectations)),
+		Unexpected:   []string{},
+	}
+	if len(expectations) == 0 {
+		return report
+	}
+
+	start := expectations[0].After
+	for i, e := range expectations {
+		report.Expectations[i] = expectationResult{expectation: *e, Matched: []string{}}
+		start = min(start, e.After)
+	}
+
+	for _, req := range store.Since(start) {
+		expected := false
+		for i, e := range expectations {
+			if req.Seq > e.After && e.filter.Match(req) {
+				report.Expectations[i].Matched = append(report.Expectations[i].Matched, req.ID)
+				expected = true
+			}
+		}
+		if !expected && req.Direction != requeststore.DirectionOutbound {
+			report.Unexpected = append(report.Unexpected, req.ID)
+		}
+	}
+
+	for i := range report.Expectations {
+		result := &report.Expectations[i]
+		result.Status = expectationMet
+		if len(result.Matched) < result.Count {
+			result.Status = expectationMissed
+			report.Met = false
+		}
+	}
+
+	return report
+}
+
+// parseExpectations parses an expectation or an array of them.
+func parseExpectations(body io.Reader) ([]*expectation, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	var expectations []*expectation
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &expectations)
+	} else {
+		e := new(expectation)
+		err = json.Unmarshal(data, e)
+		expectations = append(expectations, e)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expectation: %w", err)
+	}
+	if len(expectations) == 0 {
+		return nil, fmt.Errorf("invalid expectation: empty list")
+	}
+
+	for _, e := range expectations {
+		if e == nil {
+			return nil, fmt.Errorf("invalid expectation: null")
+		}
+		if e.Count < 0 {
+			return nil, fmt.Errorf("invalid expectation count %d", e.Count)
+		}
+		if e.Count == 0 {
+			e.Count = 1
+		}
+		if e.filter, err = parseCapturedFilter(url.Values(e.Match)); err != nil {
+			return nil, fmt.Errorf("invalid expectation %q: %w", e.Name, err)
+		}
+	}
+
+	return expectations, nil
+}
+
+// matchValues are filter query parameters given as a JSON object, values are
+// strings or arrays of strings, e.g. {"method": "POST", "json": ["$.a", "$.b=1"]}.
+type matchValues url.Values
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *matchValues) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid match: %w", err)
+	}
+
+	values := make(matchValues, len(raw))
+	for key, value := range raw {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			values[key] = []string{s}
+
+			continue
+		}
+
+		var list []string
+		if err := json.Unmarshal(value, &list); err != nil {
+			return fmt.Errorf("match %q: expected a string or an array of strings", key)
+		}
+		values[key] = list
+	}
+	*m = values
+
+	return nil
+}
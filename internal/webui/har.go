@@ -0,0 +1,141 @@
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/har"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const (
+	maxHARImportSize = 32 << 20 // 32MB
+	maxHARInlineBody = 10 << 20 // 10MB, larger spooled bodies are exported truncated
+)
+
+type harImportResponse struct {
+	Imported int      `json:"imported"`
+	IDs      []string `json:"ids"`
+}
+
+// harHandler exports stored requests as HAR on GET (all, or the ones given
+// with repeated "id" query parameters) and imports a HAR archive on POST.
+func (w *WebUI) harHandler(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.harExport(rw, r)
+	case http.MethodPost:
+		w.harImport(rw, r)
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (w *WebUI) harExport(rw http.ResponseWriter, r *http.Request) {
+	requests, ok := w.selectedRequests(rw, r)
+	if !ok {
+		return
+	}
+
+	for i, req := range requests {
+		full, err := withInlineBody(req)
+		if err != nil {
+			http.Error(rw, "failed to read request body", http.StatusInternalServerError)
+
+			return
+		}
+		requests[i] = full
+	}
+
+	rw.Header().Set(headerContentType, contentTypeJSON)
+	rw.Header().Set("Content-Disposition", `attachment; filename="requests.har"`)
+
+	enc := json.NewEncoder(rw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(har.Export(requests)); err != nil {
+		http.Error(rw, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (w *WebUI) harImport(rw http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxHARImportSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(rw, "har file too large", http.StatusRequestEntityTooLarge)
+
+			return
+		}
+		http.Error(rw, "invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	requests, err := har.Import(data)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	resp := harImportResponse{IDs: make([]string, 0, len(requests))}
+	for _, req := range requests {
+		resp.IDs = append(resp.IDs, w.storeOf(r).Add(req))
+	}
+	resp.Imported = len(resp.IDs)
+
+	rw.Header().Set(headerContentType, contentTypeJSON)
+	_ = json.NewEncoder(rw).Encode(resp)
+}
+
+// selectedRequests returns requests given with repeated "id" query parameters,
+// or all requests if none given, in chronological order. Responds with 404
+// and returns false if a request is not found.
+func (w *WebUI) selectedRequests(rw http.ResponseWriter, r *http.Request) ([]requeststore.Request, bool) {
+	ids := r.URL.Query()["id"]
+	if len(ids) == 0 {
+		requests := w.storeOf(r).GetAll()
+		slices.Reverse(requests)
+
+		return requests, true
+	}
+
+	requests := make([]requeststore.Request, 0, len(ids))
+	for _, id := range ids {
+		found, ok := w.storeOf(r).Get(id)
+		if !ok {
+			http.Error(rw, "request not found: "+id, http.StatusNotFound)
+
+			return nil, false
+		}
+		requests = append(requests, found)
+	}
+
+	return requests, true
+}
+
+// withInlineBody returns req with spooled body loaded from disk, bodies larger
+// than maxHARInlineBody keep their in memory preview.
+func withInlineBody(req requeststore.Request) (requeststore.Request, error) {
+	if req.BodyInfo == nil || !req.BodyInfo.Spooled || req.BodyInfo.Size > maxHARInlineBody {
+		return req, nil
+	}
+
+	body, err := openBody(req)
+	if err != nil {
+		return req, err
+	}
+	defer func() { _ = body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxHARInlineBody))
+	if err != nil {
+		return req, fmt.Errorf("spooled body read error: %w", err)
+	}
+	req.Body = string(data)
+
+	return req, nil
+}
@@ -0,0 +1,104 @@
+package webui
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+	"github.com/vbyazilim/basichttpdebugger/internal/snippet"
+)
+
+const httpFileName = "requests.http"
+
+// httpFileHandler exports stored requests (all, or the ones given with
+// repeated "id" query parameters) as a single REST Client .http file. If a
+// body can not be written as text, a zip archive of the .http file and the
+// "<id>.body" files it references is returned instead.
+func (w *WebUI) httpFileHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	requests, ok := w.selectedRequests(rw, r)
+	if !ok {
+		return
+	}
+
+	if !slices.ContainsFunc(requests, snippet.NeedsBodyFile) {
+		rw.Header().Set(headerContentType, "text/plain; charset=utf-8")
+		rw.Header().Set("Content-Disposition", `attachment; filename="`+httpFileName+`"`)
+
+		_, _ = io.WriteString(rw, snippet.HTTPFile(requests))
+
+		return
+	}
+
+	// bodies are opened first, errors can not be reported after the archive
+	// is started
+	bodies := make(map[string]io.ReadCloser)
+	defer func() {
+		for _, body := range bodies {
+			_ = body.Close()
+		}
+	}()
+
+	for _, req := range requests {
+		if !snippet.NeedsBodyFile(req) {
+			continue
+		}
+
+		body, err := openBody(req)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+		if body != nil {
+			bodies[req.ID] = body
+		}
+	}
+
+	rw.Header().Set(headerContentType, "application/zip")
+	rw.Header().Set("Content-Disposition", `attachment; filename="requests.zip"`)
+
+	_ = writeHTTPFileZip(rw, requests, bodies)
+}
+
+// writeHTTPFileZip writes a zip archive of the .http file of requests and
+// bodies as "<id>.body" files.
+func writeHTTPFileZip(w io.Writer, requests []requeststore.Request, bodies map[string]io.ReadCloser) error {
+	zw := zip.NewWriter(w)
+
+	f, err := zw.Create(httpFileName)
+	if err != nil {
+		return fmt.Errorf("zip create error: %w", err)
+	}
+	if _, err = io.WriteString(f, snippet.HTTPFile(requests)); err != nil {
+		return fmt.Errorf("zip write error: %w", err)
+	}
+
+	for _, req := range requests {
+		body, ok := bodies[req.ID]
+		if !ok {
+			continue
+		}
+
+		if f, err = zw.Create(req.ID + ".body"); err != nil {
+			return fmt.Errorf("zip create error: %w", err)
+		}
+		if _, err = io.Copy(f, body); err != nil {
+			return fmt.Errorf("zip write error: %w", err)
+		}
+	}
+
+	if err = zw.Close(); err != nil {
+		return fmt.Errorf("zip close error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package webui
+
+import (
+	"net/http"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/metrics"
+)
+
+// WithMetrics serves m with store metrics at "/metrics".
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(w *WebUI) {
+		w.metrics = m
+	}
+}
+
+// metricsHandler writes captured request metrics and store metrics of the
+// default store collected on scrape, in Prometheus text exposition format.
+func (w *WebUI) metricsHandler(rw http.ResponseWriter, r *http.Request) {
+	if w.metrics == nil || binOf(r) != nil {
+		http.NotFound(rw, r)
+
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	rw.Header().Set(headerContentType, metrics.ContentType)
+	if err := w.metrics.Write(rw); err != nil {
+		return
+	}
+
+	stats := w.store.Stats()
+	metrics.WriteMetric(rw, "store_requests", "gauge", "Requests in the store.", float64(stats.Total))
+	metrics.WriteMetric(rw, "store_max_requests", "gauge", "Capacity of the store.", float64(stats.Max))
+	metrics.WriteMetric(rw, "store_pinned_requests", "gauge", "Pinned requests in the store.", float64(stats.Pinned))
+	metrics.WriteMetric(rw, "store_evictions_total", "counter",
+		"Requests evicted because the store was full.", float64(stats.Evicted))
+	metrics.WriteMetric(rw, "sse_subscribers", "gauge",
+		"Open /events streams, bin streams included.", float64(w.streams.Load()))
+	if w.bins != nil {
+		metrics.WriteMetric(rw, "bins", "gauge", "Bins.", float64(len(w.bins.List())))
+	}
+}
@@ -0,0 +1,190 @@
+package webui
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const (
+	defReplayTimeout      = 10 * time.Second
+	maxReplayRequestSize  = 32 << 20 // 32MB
+	maxReplayResponseBody = 1 << 20  // 1MB
+)
+
+// sentinel errors.
+var (
+	ErrInvalidTarget = errors.New("invalid target url")
+)
+
+// replayRequest is the payload of /api/replay. Only ID is required, other
+// fields override the stored request.
+type replayRequest struct {
+	ID            string            `json:"id"`
+	Target        string            `json:"target,omitempty"` // url or path on debug server
+	Method        string            `json:"method,omitempty"`
+	SetHeaders    map[string]string `json:"setHeaders,omitempty"`
+	RemoveHeaders []string          `json:"removeHeaders,omitempty"`
+	Body          *string           `json:"body,omitempty"` // replaces stored body if set
+}
+
+// replayResponse is the upstream response of a replayed request.
+type replayResponse struct {
+	requeststore.Response
+	Target string `json:"target"`
+}
+
+// replayHandler resends a stored request, to the debug server or to given
+// target, with optional method, header and body edits.
+func (w *WebUI) replayHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	var req replayRequest
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxReplayRequestSize)).Decode(&req); err != nil {
+		http.Error(rw, "invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	found, ok := w.storeOf(r).Get(req.ID)
+	if !ok {
+		http.Error(rw, "request not found", http.StatusNotFound)
+
+		return
+	}
+
+	target := buildDebugURL(w.debugAddr, bin.Path(found.Bin, found.URL))
+	if req.Target != "" {
+		resolved, err := resolveTarget(w.debugAddr, found.Bin, req.Target)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+		target = resolved
+	}
+
+	method := found.Method
+	if req.Method != "" {
+		method = strings.ToUpper(req.Method)
+	}
+
+	var body io.Reader
+	contentLength := bodySize(found)
+	if req.Body != nil {
+		body = strings.NewReader(*req.Body)
+		contentLength = int64(len(*req.Body))
+	} else {
+		stored, err := openBody(found)
+		if err != nil {
+			http.Error(rw, "failed to read request body", http.StatusInternalServerError)
+
+			return
+		}
+		if stored != nil {
+			defer func() { _ = stored.Close() }()
+			body = stored
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(r.Context(), method, target, body)
+	if err != nil {
+		http.Error(rw, "failed to create request: "+err.Error(), http.StatusBadRequest)
+
+		return
+	}
+	httpReq.ContentLength = contentLength
+
+	for key, value := range found.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	for _, key := range req.RemoveHeaders {
+		httpReq.Header.Del(key)
+	}
+	for key, value := range req.SetHeaders {
+		httpReq.Header.Set(key, value)
+	}
+	httpReq.Header.Set("X-Replayed-From", found.ID)
+
+	client := &http.Client{Timeout: defReplayTimeout}
+
+	start := time.Now()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		http.Error(rw, "failed to replay request: "+err.Error(), http.StatusBadGateway)
+
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	response := replayResponse{
+		Response: readResponse(resp, start),
+		Target:   target,
+	}
+
+	rw.Header().Set(headerContentType, contentTypeJSON)
+
+	_ = json.NewEncoder(rw).Encode(response)
+}
+
+// readResponse reads at most maxReplayResponseBody bytes of resp body,
+// duration is measured from start until the body is read.
+func readResponse(resp *http.Response, start time.Time) requeststore.Response {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxReplayResponseBody+1))
+	duration := time.Since(start)
+
+	response := requeststore.Response{
+		Status:     resp.StatusCode,
+		StatusText: resp.Status,
+		Proto:      resp.Proto,
+		Headers:    make(map[string]string, len(resp.Header)),
+		DurationMs: float64(duration.Microseconds()) / 1000,
+	}
+	for key, values := range resp.Header {
+		response.Headers[key] = strings.Join(values, ",")
+	}
+
+	if len(body) > maxReplayResponseBody {
+		body = body[:maxReplayResponseBody]
+		response.Truncated = true
+	}
+	response.Size = int64(len(body))
+
+	if utf8.Valid(body) {
+		response.Body = string(body)
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(body)
+		response.Encoding = "base64"
+	}
+
+	return response
+}
+
+// resolveTarget validates an absolute http(s) url, paths are resolved against
+// the debug server, in given bin if set.
+func resolveTarget(debugAddr, binToken, target string) (string, error) {
+	if strings.HasPrefix(target, "/") {
+		return buildDebugURL(debugAddr, bin.Path(binToken, target)), nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTarget, target)
+	}
+
+	return u.String(), nil
+}
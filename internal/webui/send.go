@@ -0,0 +1,217 @@
+package webui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const defSendMethod = http.MethodGet
+
+// sendRequest is the payload of /api/send. If Fields or Files are given, a
+// multipart/form-data body is built from them and Body must be empty.
+type sendRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"` // url or path on debug server
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	Fields  []sendField       `json:"fields,omitempty"`
+	Files   []sendFile        `json:"files,omitempty"`
+}
+
+type sendField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type sendFile struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType,omitempty"`
+	Data        string `json:"data"` // base64 encoded
+}
+
+// sendHandler sends a new request composed in the dashboard and stores it
+// as an outbound request, its upstream response is added as an update.
+func (w *WebUI) sendHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	var req sendRequest
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxReplayRequestSize)).Decode(&req); err != nil {
+		http.Error(rw, "invalid request body", http.StatusBadRequest)
+
+		return
+	}
+
+	var binToken string
+	if b := binOf(r); b != nil {
+		binToken = b.Token
+	}
+
+	target, err := resolveTarget(w.debugAddr, binToken, req.URL)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = defSendMethod
+	}
+
+	body := []byte(req.Body)
+	contentType := ""
+	var files []requeststore.FileAttachment
+	if len(req.Fields) > 0 || len(req.Files) > 0 {
+		if req.Body != "" {
+			http.Error(rw, "body can not be used with multipart fields or files", http.StatusBadRequest)
+
+			return
+		}
+
+		body, contentType, files, err = buildMultipart(req.Fields, req.Files)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(r.Context(), method, target, bytes.NewReader(body))
+	if err != nil {
+		http.Error(rw, "failed to create request: "+err.Error(), http.StatusBadRequest)
+
+		return
+	}
+	if len(body) == 0 {
+		httpReq.Body = http.NoBody
+		httpReq.ContentLength = 0
+	}
+
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	if contentType != "" {
+		httpReq.Header.Set(headerContentType, contentType)
+	}
+
+	// stored before sending, the response is added when it arrives
+	store := w.storeOf(r)
+	outbound := outboundRequest(httpReq, body, files)
+	outbound.ID = store.Add(outbound)
+
+	client := &http.Client{Timeout: defReplayTimeout}
+
+	start := time.Now()
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		store.SetResponse(outbound.ID, &requeststore.Response{Error: err.Error()})
+		http.Error(rw, "failed to send request: "+err.Error(), http.StatusBadGateway)
+
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	response := readResponse(resp, start)
+	if updated, ok := store.SetResponse(outbound.ID, &response); ok {
+		outbound = updated
+	} else {
+		outbound.Response = &response
+	}
+
+	rw.Header().Set(headerContentType, contentTypeJSON)
+
+	_ = json.NewEncoder(rw).Encode(outbound)
+}
+
+// outboundRequest returns the store record of an outbound request.
+func outboundRequest(req *http.Request, body []byte, files []requeststore.FileAttachment) requeststore.Request {
+	headers := make(map[string]string, len(req.Header))
+	for key, values := range req.Header {
+		headers[key] = strings.Join(values, ",")
+	}
+
+	return requeststore.Request{
+		Time:      time.Now().UTC(),
+		Method:    req.Method,
+		URL:       req.URL.RequestURI(),
+		Headers:   headers,
+		Body:      string(body),
+		Host:      req.URL.Host,
+		Proto:     req.Proto,
+		Files:     files,
+		Direction: requeststore.DirectionOutbound,
+		Target:    req.URL.String(),
+	}
+}
+
+// buildMultipart returns a multipart/form-data body of given fields and
+// files with its content type.
+func buildMultipart(
+	fields []sendField,
+	files []sendFile,
+) ([]byte, string, []requeststore.FileAttachment, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	for _, field := range fields {
+		if err := mw.WriteField(field.Name, field.Value); err != nil {
+			return nil, "", nil, fmt.Errorf("multipart field error: %w", err)
+		}
+	}
+
+	attachments := make([]requeststore.FileAttachment, 0, len(files))
+	for _, file := range files {
+		data, err := base64.StdEncoding.DecodeString(file.Data)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("invalid file data %q: %w", file.Filename, err)
+		}
+
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set(
+			"Content-Disposition",
+			mime.FormatMediaType("form-data", map[string]string{"name": file.Field, "filename": file.Filename}),
+		)
+		header.Set(headerContentType, contentType)
+
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("multipart file error: %w", err)
+		}
+		if _, err = part.Write(data); err != nil {
+			return nil, "", nil, fmt.Errorf("multipart file error: %w", err)
+		}
+
+		attachments = append(attachments, requeststore.FileAttachment{
+			FieldName:   file.Field,
+			Filename:    file.Filename,
+			ContentType: contentType,
+			Size:        len(data),
+		})
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, "", nil, fmt.Errorf("multipart close error: %w", err)
+	}
+
+	return buf.Bytes(), mw.FormDataContentType(), attachments, nil
+}
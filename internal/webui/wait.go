@@ -0,0 +1,144 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+)
+
+const (
+	defWaitTimeout = 30 * time.Second
+	maxWaitTimeout = 10 * time.Minute
+)
+
+// waitHandler blocks until a captured request matching requeststore.ParseFilter query
+// parameters is stored after sequence number "after" (default: the current
+// one, i.e. only new requests) and returns it, or returns 408 when "timeout"
+// (default 30s) expires.
+func (w *WebUI) waitHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter, err := parseCapturedFilter(query)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	timeout, err := parseWaitTimeout(query.Get("timeout"), defWaitTimeout)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	store := w.storeOf(r)
+
+	after := store.Seq()
+	if value := query.Get("after"); value != "" {
+		if after, err = strconv.ParseUint(value, 10, 64); err != nil {
+			http.Error(rw, fmt.Sprintf("invalid after %q", value), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	ctx, cancel := w.waitContext(rw, r, timeout)
+	defer cancel()
+
+	req, ok := waitFor(ctx, store, after, filter.Match)
+	if !ok {
+		http.Error(rw, "no matching request within "+timeout.String(), http.StatusRequestTimeout)
+
+		return
+	}
+
+	rw.Header().Set(headerContentType, contentTypeJSON)
+	_ = json.NewEncoder(rw).Encode(req)
+}
+
+// waitContext returns a context of r that is done after timeout or when the
+// dashboard stops, and extends the write deadline of rw accordingly.
+func (w *WebUI) waitContext(
+	rw http.ResponseWriter,
+	r *http.Request,
+	timeout time.Duration,
+) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	stop := context.AfterFunc(w.ctx, cancel)
+
+	_ = http.NewResponseController(rw).SetWriteDeadline(time.Now().Add(timeout + defWriteTimeout))
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// waitFor returns the first request stored after sequence number after that
+// satisfies match, waiting for new requests until ctx is done.
+func waitFor(
+	ctx context.Context,
+	store *requeststore.Store,
+	after uint64,
+	match func(requeststore.Request) bool,
+) (requeststore.Request, bool) {
+	ch := store.Subscribe()
+	defer store.Unsubscribe(ch)
+
+	for {
+		for _, req := range store.Since(after) {
+			after = req.Seq
+			if match(req) {
+				return req, true
+			}
+		}
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return requeststore.Request{}, false
+		}
+	}
+}
+
+// parseCapturedFilter parses filter query parameters, the filter matches only
+// captured requests unless a direction is given, i.e. requests sent from the
+// dashboard do not satisfy waits and expectations.
+func parseCapturedFilter(query url.Values) (requeststore.Filter, error) {
+	filter, err := requeststore.ParseFilter(query)
+	if err != nil {
+		return filter, fmt.Errorf("filter parse error: %w", err)
+	}
+	if filter.Direction == "" {
+		filter.Direction = requeststore.DirectionCaptured
+	}
+
+	return filter, nil
+}
+
+// parseWaitTimeout parses a duration such as "30s", def if empty.
+func parseWaitTimeout(value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 || timeout > maxWaitTimeout {
+		return 0, fmt.Errorf("invalid timeout %q, expected a duration up to %s", value, maxWaitTimeout)
+	}
+
+	return timeout, nil
+}
@@ -8,11 +8,19 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/metrics"
 	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
+	"github.com/vbyazilim/basichttpdebugger/internal/snippet"
 )
 
 //go:embed static/index.html
@@ -26,39 +34,102 @@ const (
 
 	headerContentType = "Content-Type"
 	contentTypeJSON   = "application/json"
+
+	maxAnnotationSize = 1 << 20 // 1MB
+
+	defHeartbeatInterval = 15 * time.Second
 )
 
 // WebUI represents the web dashboard server.
 type WebUI struct {
-	store      *requeststore.Store
-	listenAddr string
-	debugAddr  string
-	server     *http.Server
-	cancel     context.CancelFunc
-	ctx        context.Context
+	store       *requeststore.Store
+	bins        *bin.Registry
+	metrics     *metrics.Metrics
+	credentials []Credential
+	corsOrigins []string
+	prefix      string
+	heartbeat   time.Duration
+	listenAddr  string
+	debugAddr   string
+	server      *http.Server
+	err         error
+	cancel      context.CancelFunc
+	ctx         context.Context
+
+	expectationsMu sync.Mutex
+	expectations   map[*requeststore.Store][]*expectation
+
+	streams atomic.Int64 // open /events streams of all stores
+}
+
+// Option represents option function type.
+type Option func(*WebUI)
+
+// WithBins sets the bin registry, enables bin management and bin scoped
+// dashboards under "/bins/{token}/".
+func WithBins(r *bin.Registry) Option {
+	return func(w *WebUI) {
+		w.bins = r
+	}
+}
+
+// WithPrefix serves the dashboard under given path prefix, e.g.
+// "/__debugger", used when the dashboard is mounted on the debug server.
+func WithPrefix(prefix string) Option {
+	return func(w *WebUI) {
+		w.prefix = strings.TrimSuffix("/"+strings.Trim(prefix, "/"), "/")
+	}
 }
 
 // New creates a new WebUI instance.
-func New(store *requeststore.Store, listenAddr, debugAddr string) *WebUI {
+func New(store *requeststore.Store, listenAddr, debugAddr string, options ...Option) *WebUI {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	w := &WebUI{
 		store:      store,
 		listenAddr: listenAddr,
 		debugAddr:  debugAddr,
-		ctx:        ctx,
-		cancel:     cancel,
+		heartbeat:  defHeartbeatInterval,
+
+		expectations: make(map[*requeststore.Store][]*expectation),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+
+	for _, opt := range options {
+		opt(w)
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", w.dashboardHandler)
 	mux.HandleFunc("/events", w.eventsHandler)
 	mux.HandleFunc("/api/requests", w.requestsHandler)
+	mux.HandleFunc("/api/requests/{id}", w.requestHandler)
+	mux.HandleFunc("/api/requests/{id}/body", w.bodyHandler)
+	mux.HandleFunc("/api/requests/{id}/files/{index}", w.fileHandler)
+	mux.HandleFunc("/api/requests/{id}/snippet", w.snippetHandler)
 	mux.HandleFunc("/api/replay", w.replayHandler)
+	mux.HandleFunc("/api/send", w.sendHandler)
+	mux.HandleFunc("/api/diff", w.diffHandler)
+	mux.HandleFunc("/api/har", w.harHandler)
+	mux.HandleFunc("/api/http-file", w.httpFileHandler)
+	mux.HandleFunc("/api/bins", w.binsHandler)
+	mux.HandleFunc("/api/bins/{token}", w.binHandler)
+	mux.HandleFunc("/api/session", w.sessionHandler)
+	mux.HandleFunc("/api/wait", w.waitHandler)
+	mux.HandleFunc("/api/expectations", w.expectationsHandler)
+	mux.HandleFunc("/metrics", w.metricsHandler)
+	mux.Handle("/bins/{token}/", w.binScope(mux))
+
+	handler, err := w.secure(mux)
+	if err != nil {
+		w.err = err
+		handler = http.NotFoundHandler()
+	}
 
 	w.server = &http.Server{
 		Addr:              listenAddr,
-		Handler:           mux,
+		Handler:           handler,
 		ReadTimeout:       defReadTimeout,
 		ReadHeaderTimeout: defReadHeaderTimeout,
 		WriteTimeout:      defWriteTimeout,
@@ -70,6 +141,10 @@ func New(store *requeststore.Store, listenAddr, debugAddr string) *WebUI {
 
 // Start starts the web dashboard server.
 func (w *WebUI) Start() error {
+	if w.err != nil {
+		return fmt.Errorf("webui start error: %w", w.err)
+	}
+
 	log.Printf("web dashboard available at http://localhost%s\n", w.listenAddr)
 
 	if err := w.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -94,6 +169,33 @@ func (w *WebUI) Stop() error {
 	return nil
 }
 
+// Prefix returns the path prefix of the dashboard, empty if it is served at
+// the root.
+func (w *WebUI) Prefix() string {
+	return w.prefix
+}
+
+// Handler returns the dashboard handler, it expects request paths with the
+// prefix, if any. Prefixed handlers served by another server get the write
+// timeout of the dashboard server. Returns the dashboard configuration error,
+// if any.
+func (w *WebUI) Handler() (http.Handler, error) {
+	if w.err != nil {
+		return nil, fmt.Errorf("webui handler error: %w", w.err)
+	}
+
+	if w.prefix == "" {
+		return w.server.Handler, nil
+	}
+
+	handler := http.StripPrefix(w.prefix, w.server.Handler)
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		_ = http.NewResponseController(rw).SetWriteDeadline(time.Now().Add(defWriteTimeout))
+		handler.ServeHTTP(rw, r)
+	}), nil
+}
+
 // ListenAddr returns the listen address.
 func (w *WebUI) ListenAddr() string {
 	return w.listenAddr
@@ -117,141 +219,258 @@ func (*WebUI) dashboardHandler(rw http.ResponseWriter, r *http.Request) {
 	_, _ = rw.Write(content)
 }
 
-func (w *WebUI) eventsHandler(rw http.ResponseWriter, r *http.Request) {
-	flusher, ok := rw.(http.Flusher)
-	if !ok {
-		http.Error(rw, "streaming unsupported", http.StatusInternalServerError)
+// requestsHandler returns stored requests, newest first, filtered with
+// requeststore.ParseFilter query parameters. "limit" and "cursor" paginate
+// results; the next cursor is returned in X-Next-Cursor header and the number
+// of matching requests in X-Total-Count header. DELETE clears the store.
+func (w *WebUI) requestsHandler(rw http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		deleted := w.storeOf(r).Clear()
+
+		rw.Header().Set(headerContentType, contentTypeJSON)
+		_ = json.NewEncoder(rw).Encode(map[string]int{"deleted": deleted})
+
+		return
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
 
 		return
 	}
 
-	rw.Header().Set("Content-Type", "text/event-stream")
-	rw.Header().Set("Cache-Control", "no-cache")
-	rw.Header().Set("Connection", "keep-alive")
-	rw.Header().Set("Access-Control-Allow-Origin", "*")
+	query := r.URL.Query()
 
-	ch := w.store.Subscribe()
-	defer w.store.Unsubscribe(ch)
+	filter, err := requeststore.ParseFilter(query)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
 
-	// Send initial comment to establish connection
-	fmt.Fprint(rw, ": connected\n\n")
-	flusher.Flush()
+		return
+	}
 
-	for {
-		select {
-		case req := <-ch:
-			data, err := json.Marshal(req)
-			if err != nil {
-				continue
-			}
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			http.Error(rw, "invalid limit", http.StatusBadRequest)
 
-			fmt.Fprintf(rw, "data: %s\n\n", data)
-			flusher.Flush()
-		case <-r.Context().Done():
-			return
-		case <-w.ctx.Done():
 			return
 		}
 	}
-}
 
-func (w *WebUI) requestsHandler(rw http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	page, err := w.storeOf(r).Find(filter, query.Get("cursor"), limit)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
 
 		return
 	}
 
-	requests := w.store.GetAll()
-
 	rw.Header().Set(headerContentType, contentTypeJSON)
-	rw.Header().Set("Access-Control-Allow-Origin", "*")
+	rw.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.Next != "" {
+		rw.Header().Set("X-Next-Cursor", page.Next)
+	}
 
-	if err := json.NewEncoder(rw).Encode(requests); err != nil {
+	if err := json.NewEncoder(rw).Encode(page.Requests); err != nil {
 		http.Error(rw, "internal server error", http.StatusInternalServerError)
 
 		return
 	}
 }
 
-type replayRequest struct {
-	ID string `json:"id"`
+// requestHandler returns (GET), annotates (PATCH, with a
+// requeststore.Annotation body) or deletes (DELETE) a single request.
+func (w *WebUI) requestHandler(rw http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	switch r.Method {
+	case http.MethodGet:
+		found, ok := w.storeOf(r).Get(id)
+		if !ok {
+			http.Error(rw, "request not found", http.StatusNotFound)
+
+			return
+		}
+
+		rw.Header().Set(headerContentType, contentTypeJSON)
+		_ = json.NewEncoder(rw).Encode(found)
+	case http.MethodPatch:
+		var annotation requeststore.Annotation
+		if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxAnnotationSize)).Decode(&annotation); err != nil {
+			http.Error(rw, "invalid request body", http.StatusBadRequest)
+
+			return
+		}
+
+		updated, ok := w.storeOf(r).Annotate(id, annotation)
+		if !ok {
+			http.Error(rw, "request not found", http.StatusNotFound)
+
+			return
+		}
+
+		rw.Header().Set(headerContentType, contentTypeJSON)
+		_ = json.NewEncoder(rw).Encode(updated)
+	case http.MethodDelete:
+		if !w.storeOf(r).Delete(id) {
+			http.Error(rw, "request not found", http.StatusNotFound)
+
+			return
+		}
+
+		rw.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
 }
 
-func (w *WebUI) replayHandler(rw http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+func (w *WebUI) bodyHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
 
 		return
 	}
 
-	var req replayRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(rw, "invalid request body", http.StatusBadRequest)
+	found, ok := w.storeOf(r).Get(r.PathValue("id"))
+	if !ok {
+		http.Error(rw, "request not found", http.StatusNotFound)
 
 		return
 	}
 
-	requests := w.store.GetAll()
+	body, err := openBody(found)
+	if err != nil {
+		http.Error(rw, "failed to read request body", http.StatusInternalServerError)
 
-	var found *requeststore.Request
+		return
+	}
+	if body == nil {
+		rw.WriteHeader(http.StatusNoContent)
 
-	for i := range requests {
-		if requests[i].ID == req.ID {
-			found = &requests[i]
+		return
+	}
+	defer func() { _ = body.Close() }()
 
-			break
-		}
+	contentType := found.Headers[headerContentType]
+	if contentType == "" {
+		contentType = "application/octet-stream"
 	}
 
-	if found == nil {
+	rw.Header().Set(headerContentType, contentType)
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", found.ID+".body"))
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.Header().Set("Content-Length", strconv.FormatInt(bodySize(found), 10))
+
+	_, _ = io.Copy(rw, body)
+}
+
+func (w *WebUI) fileHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	found, ok := w.storeOf(r).Get(r.PathValue("id"))
+	if !ok {
 		http.Error(rw, "request not found", http.StatusNotFound)
 
 		return
 	}
 
-	debugURL := buildDebugURL(w.debugAddr, found.URL)
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil || index < 0 || index >= len(found.Files) || found.Files[index].Path == "" {
+		http.Error(rw, "file not found", http.StatusNotFound)
 
-	var bodyReader io.Reader
-	if found.Body != "" {
-		bodyReader = strings.NewReader(found.Body)
+		return
 	}
+	attachment := found.Files[index]
 
-	httpReq, err := http.NewRequestWithContext(r.Context(), found.Method, debugURL, bodyReader)
+	f, err := os.Open(attachment.Path)
 	if err != nil {
-		http.Error(rw, "failed to create request", http.StatusInternalServerError)
+		http.Error(rw, "file not found", http.StatusNotFound)
 
 		return
 	}
+	defer func() { _ = f.Close() }()
 
-	for key, value := range found.Headers {
-		httpReq.Header.Set(key, value)
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
 	}
 
-	httpReq.Header.Set("X-Replayed-From", found.ID)
+	rw.Header().Set(headerContentType, contentType)
+	rw.Header().Set(
+		"Content-Disposition",
+		mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+	)
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	if info, errStat := f.Stat(); errStat == nil {
+		rw.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	}
+
+	_, _ = io.Copy(rw, f)
+}
+
+// snippetHandler returns code reproducing the request, format is given with
+// "format" query parameter (curl by default).
+func (w *WebUI) snippetHandler(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	found, ok := w.storeOf(r).Get(r.PathValue("id"))
+	if !ok {
+		http.Error(rw, "request not found", http.StatusNotFound)
+
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = snippet.FormatCurl
+	}
 
-	resp, err := client.Do(httpReq)
+	code, err := snippet.Generate(found, format)
 	if err != nil {
-		http.Error(rw, "failed to replay request: "+err.Error(), http.StatusBadGateway)
+		http.Error(rw, err.Error(), http.StatusBadRequest)
 
 		return
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	body, _ := io.ReadAll(resp.Body)
+	rw.Header().Set(headerContentType, "text/plain; charset=utf-8")
+	_, _ = io.WriteString(rw, code)
+}
 
-	rw.Header().Set(headerContentType, contentTypeJSON)
-	rw.Header().Set("Access-Control-Allow-Origin", "*")
+// openBody returns a reader for the complete body of given request, reading
+// spooled bodies from disk. Returns nil if request has no body.
+func openBody(req requeststore.Request) (io.ReadCloser, error) {
+	if req.BodyInfo != nil && req.BodyInfo.Spooled {
+		f, err := os.Open(req.BodyInfo.Path)
+		if err != nil {
+			return nil, fmt.Errorf("spooled body open error: %w", err)
+		}
+
+		return f, nil
+	}
+
+	if req.Body == "" {
+		return nil, nil
+	}
+
+	return io.NopCloser(strings.NewReader(req.Body)), nil
+}
 
-	response := map[string]any{
-		"status":     resp.StatusCode,
-		"statusText": resp.Status,
-		"body":       string(body),
+// bodySize returns size of the complete body of given request.
+func bodySize(req requeststore.Request) int64 {
+	if req.BodyInfo != nil {
+		return req.BodyInfo.Size
 	}
 
-	_ = json.NewEncoder(rw).Encode(response)
+	return int64(len(req.Body))
 }
 
 // buildDebugURL constructs the debug server URL from the listen address.
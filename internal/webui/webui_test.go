@@ -1,18 +1,28 @@
 package webui
 
 import (
+	"archive/zip"
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vbyazilim/basichttpdebugger/internal/bin"
+	"github.com/vbyazilim/basichttpdebugger/internal/diff"
+	"github.com/vbyazilim/basichttpdebugger/internal/har"
+	"github.com/vbyazilim/basichttpdebugger/internal/metrics"
 	"github.com/vbyazilim/basichttpdebugger/internal/requeststore"
 )
 
@@ -41,6 +51,35 @@ func TestNew(t *testing.T) {
 	assert.Equal(t, ":9003", webui.ListenAddr())
 }
 
+func TestWebUI_Handler(t *testing.T) {
+	store := requeststore.New(50)
+	bins := bin.NewRegistry(10, 10)
+	_, err := bins.Create(bin.Config{Token: "alice"})
+	require.NoError(t, err)
+
+	for _, prefix := range []string{"__debugger", "/__debugger/", "/__debugger"} {
+		webui := New(store, ":9002", ":9002", WithPrefix(prefix), WithBins(bins))
+		assert.Equal(t, "/__debugger", webui.Prefix())
+
+		handler, err := webui.Handler()
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/__debugger/api/bins/alice", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `"/__debugger/bins/alice/"`, jsonField(t, rec.Body.Bytes(), "dashboard"))
+	}
+
+	webui := New(store, ":9003", ":9002")
+	assert.Empty(t, webui.Prefix())
+
+	handler, err := webui.Handler()
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
 func TestBuildDebugURL(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -147,6 +186,76 @@ func TestWebUI_requestsHandler(t *testing.T) {
 		assert.Equal(t, "POST", requests[0].Method)
 	})
 
+	t.Run("filters and paginates requests", func(t *testing.T) {
+		store := requeststore.New(50)
+		for i := range 5 {
+			store.Add(requeststore.Request{
+				ID:     fmt.Sprintf("req-%d", i),
+				Method: "POST",
+				URL:    fmt.Sprintf("/hooks/%d", i),
+				Body:   fmt.Sprintf(`{"n": %d, "kind": "%s"}`, i, map[bool]string{true: "even", false: "odd"}[i%2 == 0]),
+			})
+		}
+		store.Add(requeststore.Request{ID: "get", Method: "GET", URL: "/hooks/x"})
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/requests?method=POST&json=$.kind=even&limit=2", nil)
+		rec := httptest.NewRecorder()
+
+		webui.requestsHandler(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "3", rec.Header().Get("X-Total-Count"))
+		assert.Equal(t, "req-2", rec.Header().Get("X-Next-Cursor"))
+
+		var requests []requeststore.Request
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &requests))
+		require.Len(t, requests, 2)
+		assert.Equal(t, "req-4", requests[0].ID)
+		assert.Equal(t, "req-2", requests[1].ID)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/requests?method=POST&json=$.kind=even&limit=2&cursor=req-2", nil)
+		rec = httptest.NewRecorder()
+
+		webui.requestsHandler(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("X-Next-Cursor"))
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &requests))
+		require.Len(t, requests, 1)
+		assert.Equal(t, "req-0", requests[0].ID)
+	})
+
+	t.Run("rejects invalid filter and pagination", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		for _, query := range []string{"pathRegex=(", "limit=-1", "limit=x", "cursor=missing"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/requests?"+query, nil)
+			rec := httptest.NewRecorder()
+
+			webui.requestsHandler(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+
+	t.Run("clears requests on DELETE", func(t *testing.T) {
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{ID: "a"})
+		store.Add(requeststore.Request{ID: "b"})
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/requests", nil)
+		rec := httptest.NewRecorder()
+
+		webui.requestsHandler(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"deleted":2}`, rec.Body.String())
+		assert.Equal(t, 0, store.Count())
+	})
+
 	t.Run("rejects non-GET methods", func(t *testing.T) {
 		store := requeststore.New(50)
 		webui := New(store, ":9003", ":9002")
@@ -160,6 +269,173 @@ func TestWebUI_requestsHandler(t *testing.T) {
 	})
 }
 
+func TestWebUI_requestHandler(t *testing.T) {
+	store := requeststore.New(50)
+	store.Add(requeststore.Request{ID: "one", Method: "POST", URL: "/hook"})
+	store.Add(requeststore.Request{ID: "two", Method: "GET", URL: "/"})
+	webui := New(store, ":9003", ":9002")
+
+	t.Run("returns single request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/one", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var got requeststore.Request
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, "one", got.ID)
+		assert.Equal(t, "/hook", got.URL)
+	})
+
+	t.Run("annotates single request", func(t *testing.T) {
+		body := `{"pinned":true,"tags":["deploy"," deploy ","bug"],"notes":"look here"}`
+		req := httptest.NewRequest(http.MethodPatch, "/api/requests/two", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var got requeststore.Request
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.True(t, got.Pinned)
+		assert.Equal(t, []string{"deploy", "bug"}, got.Tags)
+		assert.Equal(t, "look here", got.Notes)
+
+		stored, _ := store.Get("two")
+		assert.Equal(t, got.Tags, stored.Tags)
+	})
+
+	t.Run("rejects invalid annotation", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/api/requests/two", strings.NewReader(`{"pinned":"yes"}`))
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("deletes single request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/requests/one", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		_, ok := store.Get("one")
+		assert.False(t, ok)
+		assert.Equal(t, 1, store.Count())
+	})
+
+	t.Run("returns not found for unknown request ID", func(t *testing.T) {
+		for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+			req := httptest.NewRequest(method, "/api/requests/one", strings.NewReader(`{}`))
+			rec := httptest.NewRecorder()
+
+			webui.server.Handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code, method)
+		}
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/api/requests/two", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+// sseEvent is a parsed frame of the /events stream.
+type sseEvent struct {
+	name string
+	id   string
+	data string
+}
+
+// readEvent reads the next event frame, skipping comments.
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var ev sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if ev.name != "" {
+				return ev
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event: "):
+			ev.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// eventData returns data of the envelope of ev after checking its version
+// and type.
+func eventData(t *testing.T, ev sseEvent) string {
+	t.Helper()
+
+	var envelope struct {
+		Version int             `json:"version"`
+		Type    string          `json:"type"`
+		Data    json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(ev.data), &envelope))
+	assert.Equal(t, EventVersion, envelope.Version)
+	assert.Equal(t, ev.name, envelope.Type)
+
+	return string(envelope.Data)
+}
+
+// streamEvents starts eventsHandler for req, returns a reader positioned
+// after the connected comment.
+func streamEvents(t *testing.T, webui *WebUI, req *http.Request) *bufio.Reader {
+	t.Helper()
+
+	pr, pw := io.Pipe()
+	rec := &mockResponseWriter{header: http.Header{}, writer: pw}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	t.Cleanup(func() {
+		cancel()
+		_ = pr.Close()
+	})
+
+	go webui.eventsHandler(rec, req.WithContext(ctx))
+
+	reader := bufio.NewReader(pr)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, ": connected\n", line)
+
+	return reader
+}
+
+// skipStats reads events until the first non stats event.
+func skipStats(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+
+	for {
+		ev := readEvent(t, reader)
+		if ev.name != EventStats {
+			return ev
+		}
+	}
+}
+
 func TestWebUI_eventsHandler(t *testing.T) {
 	t.Run("sets SSE headers", func(t *testing.T) {
 		store := requeststore.New(50)
@@ -183,10 +459,11 @@ func TestWebUI_eventsHandler(t *testing.T) {
 			close(handlerDone)
 		}()
 
-		// Read the initial ": connected" comment (io.Pipe is unbuffered, must read to unblock writer)
+		// Read the initial ": connected" comment and stats event (io.Pipe is
+		// unbuffered, must read to unblock writer)
 		reader := bufio.NewReader(pr)
 		_, _ = reader.ReadString('\n')
-		_, _ = reader.ReadString('\n')
+		_ = readEvent(t, reader)
 
 		// Stop the webui to terminate the handler
 		_ = webui.Stop()
@@ -203,51 +480,328 @@ func TestWebUI_eventsHandler(t *testing.T) {
 		pw.Close()
 	})
 
+	t.Run("sends stats on connect", func(t *testing.T) {
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{Method: http.MethodPost})
+		webui := New(store, ":9003", ":9002")
+
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+		ev := readEvent(t, reader)
+		assert.Equal(t, EventStats, ev.name)
+		assert.Empty(t, ev.id)
+
+		var stats requeststore.Stats
+		require.NoError(t, json.Unmarshal([]byte(eventData(t, ev)), &stats))
+		assert.Equal(t, 1, stats.Total)
+		assert.Equal(t, 50, stats.Max)
+		assert.Equal(t, map[string]int{http.MethodPost: 1}, stats.Methods)
+		assert.Equal(t, 1, stats.Listeners)
+	})
+
 	t.Run("broadcasts new requests", func(t *testing.T) {
 		store := requeststore.New(50)
 		webui := New(store, ":9003", ":9002")
 
-		pr, pw := io.Pipe()
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+		_ = readEvent(t, reader)
 
-		rec := &mockResponseWriter{
-			header: http.Header{},
-			writer: pw,
-		}
+		store.Add(requeststore.Request{
+			ID:     "sse-test",
+			Method: "GET",
+			URL:    "/test",
+		})
+
+		ev := readEvent(t, reader)
+		assert.Equal(t, EventRequest, ev.name)
+		assert.Equal(t, "1", ev.id)
+
+		var got requeststore.Request
+		require.NoError(t, json.Unmarshal([]byte(eventData(t, ev)), &got))
+		assert.Equal(t, "sse-test", got.ID)
+		assert.Equal(t, uint64(1), got.Seq)
+
+		// changes are followed by a stats event
+		ev = readEvent(t, reader)
+		assert.Equal(t, EventStats, ev.name)
+		assert.Contains(t, eventData(t, ev), `"total":1`)
+	})
+
+	t.Run("streams only matching requests", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events?method=POST", nil))
+		_ = readEvent(t, reader)
+
+		store.Add(requeststore.Request{ID: "sse-get", Method: "GET", URL: "/test"})
+		store.Add(requeststore.Request{ID: "sse-post", Method: "POST", URL: "/test"})
+
+		ev := skipStats(t, reader)
+		assert.Equal(t, EventRequest, ev.name)
+		assert.Equal(t, "2", ev.id)
+		assert.Contains(t, eventData(t, ev), "sse-post")
+	})
+
+	t.Run("streams updates, deletions and clears", func(t *testing.T) {
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{ID: "sse-delete"})
+		webui := New(store, ":9003", ":9002")
+
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+		_ = readEvent(t, reader)
+
+		go store.Delete("sse-delete")
+
+		ev := skipStats(t, reader)
+		assert.Equal(t, EventDeleted, ev.name)
+		assert.JSONEq(t, `{"ids":["sse-delete"]}`, eventData(t, ev))
+
+		go store.Clear()
+
+		ev = skipStats(t, reader)
+		assert.Equal(t, EventCleared, ev.name)
+		assert.JSONEq(t, `{"cleared":true}`, eventData(t, ev))
+
+		store.Add(requeststore.Request{ID: "sse-pin"})
+		ev = skipStats(t, reader)
+		assert.Equal(t, EventRequest, ev.name)
+
+		pinned := true
+		go store.Annotate("sse-pin", requeststore.Annotation{Pinned: &pinned})
+
+		ev = skipStats(t, reader)
+		assert.Equal(t, EventRequestUpdated, ev.name)
+		assert.Empty(t, ev.id)
+		assert.Contains(t, eventData(t, ev), `"pinned":true`)
+	})
+
+	t.Run("streams requests before their updates", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
 
-		ctx, cancel := context.WithCancel(context.Background())
-		req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+		_ = readEvent(t, reader)
 
 		go func() {
-			webui.eventsHandler(rec, req)
+			id := store.Add(requeststore.Request{Direction: requeststore.DirectionOutbound})
+			store.SetResponse(id, &requeststore.Response{Status: http.StatusCreated})
 		}()
 
-		reader := bufio.NewReader(pr)
+		ev := skipStats(t, reader)
+		assert.Equal(t, EventRequest, ev.name)
+
+		ev = skipStats(t, reader)
+		assert.Equal(t, EventRequestUpdated, ev.name)
+		assert.Contains(t, eventData(t, ev), `"status":201`)
+	})
+
+	t.Run("replays requests after last event id", func(t *testing.T) {
+		store := requeststore.New(50)
+		for _, id := range []string{"seen", "missed-get", "missed-post"} {
+			method := http.MethodPost
+			if id == "missed-get" {
+				method = http.MethodGet
+			}
+			store.Add(requeststore.Request{ID: id, Method: method})
+		}
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/events?method=POST", nil)
+		req.Header.Set("Last-Event-ID", "1")
+		reader := streamEvents(t, webui, req)
+
+		ev := readEvent(t, reader)
+		assert.Equal(t, EventRequest, ev.name)
+		assert.Equal(t, "3", ev.id)
+		assert.Contains(t, eventData(t, ev), "missed-post")
+
+		store.Add(requeststore.Request{ID: "live", Method: http.MethodPost})
+
+		ev = skipStats(t, reader)
+		assert.Equal(t, "4", ev.id)
+	})
+
+	t.Run("announces and replays dropped requests", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+		_ = readEvent(t, reader)
+
+		store.Add(requeststore.Request{})
+		ev := readEvent(t, reader)
+		require.Equal(t, "1", ev.id)
+
+		// handler blocks writing until the stream is read, the subscriber
+		// buffer overflows meanwhile
+		for range 15 {
+			store.Add(requeststore.Request{})
+		}
+
+		ids := []string{"1"}
+		dropped := ""
+		for len(ids) < 16 {
+			ev = readEvent(t, reader)
+
+			switch ev.name {
+			case EventRequest:
+				ids = append(ids, ev.id)
+			case EventDropped:
+				dropped = eventData(t, ev)
+			}
+		}
+
+		assert.Regexp(t, `^\{"count":[1-9]\d*\}$`, dropped)
+		for i, id := range ids {
+			assert.Equal(t, strconv.Itoa(i+1), id)
+		}
+	})
+
+	t.Run("announces dropped updates with resync", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+		_ = readEvent(t, reader)
+
+		id := store.Add(requeststore.Request{})
+		_ = readEvent(t, reader)
 
-		// Read the initial ": connected" comment first (io.Pipe is unbuffered)
+		// handler blocks writing until the stream is read, the update
+		// subscriber buffer overflows meanwhile
+		for i := range 15 {
+			notes := strconv.Itoa(i)
+			_, ok := store.Annotate(id, requeststore.Annotation{Notes: &notes})
+			require.True(t, ok)
+		}
+
+		for {
+			ev := readEvent(t, reader)
+			if ev.name == EventResync {
+				assert.Regexp(t, `^\{"count":[1-9]\d*\}$`, eventData(t, ev))
+
+				break
+			}
+		}
+	})
+
+	t.Run("does not stream deleted requests", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+		_ = readEvent(t, reader)
+
+		// handler blocks writing the rest of the first event until the
+		// stream is read, the next request is deleted meanwhile
+		store.Add(requeststore.Request{ID: "first"})
 		line, err := reader.ReadString('\n')
 		require.NoError(t, err)
-		assert.Equal(t, ": connected\n", line)
+		require.Equal(t, "event: request\n", line)
+
+		store.Add(requeststore.Request{ID: "deleted"})
+		require.True(t, store.Delete("deleted"))
+		store.Add(requeststore.Request{ID: "last"})
+
+		for line != "\n" {
+			line, err = reader.ReadString('\n')
+			require.NoError(t, err)
+		}
+
+		var requests []string
+		deleted := false
+		for !deleted || len(requests) < 1 {
+			ev := readEvent(t, reader)
+
+			switch ev.name {
+			case EventRequest:
+				var req requeststore.Request
+				require.NoError(t, json.Unmarshal([]byte(eventData(t, ev)), &req))
+				requests = append(requests, req.ID)
+			case EventDeleted:
+				assert.JSONEq(t, `{"ids":["deleted"]}`, eventData(t, ev))
+				deleted = true
+			}
+		}
+
+		assert.Equal(t, []string{"last"}, requests)
+	})
+
+	t.Run("sends requests added after a clear again", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+		_ = readEvent(t, reader)
+
+		store.Add(requeststore.Request{ID: "cleared"})
+		store.Clear()
+		store.Add(requeststore.Request{ID: "kept"})
+
+		var requests []string
+		for len(requests) == 0 || requests[len(requests)-1] != "kept" {
+			ev := readEvent(t, reader)
+
+			switch ev.name {
+			case EventRequest:
+				var req requeststore.Request
+				require.NoError(t, json.Unmarshal([]byte(eventData(t, ev)), &req))
+				requests = append(requests, req.ID)
+			case EventCleared:
+				requests = nil
+			}
+		}
+
+		assert.Equal(t, []string{"kept"}, requests)
+	})
 
-		// Read the empty line after comment
-		_, err = reader.ReadString('\n')
+	t.Run("sends heartbeats", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+		webui.heartbeat = 10 * time.Millisecond
+
+		reader := streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+		_ = readEvent(t, reader)
+
+		line, err := reader.ReadString('\n')
 		require.NoError(t, err)
+		assert.Equal(t, ": heartbeat\n", line)
+	})
 
-		// Now add a request
-		store.Add(requeststore.Request{
-			ID:     "sse-test",
-			Method: "GET",
-			URL:    "/test",
-		})
+	t.Run("replays all requests after a restart", func(t *testing.T) {
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{ID: "after-restart"})
+
+		req := httptest.NewRequest(http.MethodGet, "/events?lastEventId=40", nil)
+		seq, err := lastEventID(req, store)
 
-		// Read the data line
-		line, err = reader.ReadString('\n')
 		require.NoError(t, err)
+		assert.Equal(t, uint64(0), seq)
+	})
+
+	t.Run("rejects invalid last event id", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
 
-		assert.True(t, strings.HasPrefix(line, "data: "))
-		assert.Contains(t, line, "sse-test")
+		req := httptest.NewRequest(http.MethodGet, "/events?lastEventId=abc", nil)
+		rec := httptest.NewRecorder()
 
-		cancel()
-		pw.Close()
+		webui.eventsHandler(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("rejects invalid filter", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/events?signature=maybe", nil)
+		rec := httptest.NewRecorder()
+
+		webui.eventsHandler(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
 
 	t.Run("stops gracefully when webui context is cancelled", func(t *testing.T) {
@@ -270,10 +824,11 @@ func TestWebUI_eventsHandler(t *testing.T) {
 			close(handlerDone)
 		}()
 
-		// Read the initial ": connected" comment (io.Pipe is unbuffered, must read to unblock writer)
+		// Read the initial ": connected" comment and stats event (io.Pipe is
+		// unbuffered, must read to unblock writer)
 		reader := bufio.NewReader(pr)
 		_, _ = reader.ReadString('\n')
-		_, _ = reader.ReadString('\n')
+		_ = readEvent(t, reader)
 
 		// Stop should cancel the context and cause handler to exit
 		err := webui.Stop()
@@ -373,14 +928,120 @@ func TestWebUI_replayHandler(t *testing.T) {
 		assert.Equal(t, float64(200), response["status"])
 	})
 
-	t.Run("returns bad gateway when debug server is unavailable", func(t *testing.T) {
+	t.Run("replays to target with edits", func(t *testing.T) {
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+
+			assert.Equal(t, "PUT", r.Method)
+			assert.Equal(t, "/dev/hook", r.URL.Path)
+			assert.Equal(t, "a=1", r.URL.RawQuery)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.Empty(t, r.Header.Get("X-Signature"))
+			assert.Equal(t, "edited", r.Header.Get("X-Custom"))
+			assert.Equal(t, "edit-1", r.Header.Get("X-Replayed-From"))
+			assert.Equal(t, `{"data": "edited"}`, string(body))
+			assert.Equal(t, int64(len(body)), r.ContentLength)
+
+			w.Header().Set("X-Upstream", "dev")
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte("queued"))
+		}))
+		defer target.Close()
+
 		store := requeststore.New(50)
-		// Point to a port that's not listening
 		webui := New(store, ":9003", "127.0.0.1:59999")
 
 		store.Add(requeststore.Request{
-			ID:     "replay-fail",
-			Method: "GET",
+			ID:     "edit-1",
+			Method: "POST",
+			URL:    "/webhook",
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+				"X-Signature":  "sig",
+				"X-Custom":     "original",
+			},
+			Body: `{"data": "test"}`,
+		})
+
+		payload, err := json.Marshal(map[string]any{
+			"id":            "edit-1",
+			"target":        target.URL + "/dev/hook?a=1",
+			"method":        "put",
+			"setHeaders":    map[string]string{"X-Custom": "edited"},
+			"removeHeaders": []string{"X-Signature"},
+			"body":          `{"data": "edited"}`,
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/replay", bytes.NewReader(payload))
+		rec := httptest.NewRecorder()
+
+		webui.replayHandler(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var response replayResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, http.StatusAccepted, response.Status)
+		assert.Equal(t, "202 Accepted", response.StatusText)
+		assert.Equal(t, "dev", response.Headers["X-Upstream"])
+		assert.Equal(t, "queued", response.Body)
+		assert.Empty(t, response.Encoding)
+		assert.Equal(t, int64(6), response.Size)
+		assert.Equal(t, target.URL+"/dev/hook?a=1", response.Target)
+	})
+
+	t.Run("encodes binary response as base64", func(t *testing.T) {
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte{0xff, 0xfe, 0x00})
+		}))
+		defer target.Close()
+
+		store := requeststore.New(50)
+		webui := New(store, ":9003", "127.0.0.1:59999")
+		store.Add(requeststore.Request{ID: "bin-1", Method: "GET", URL: "/"})
+
+		body := `{"id": "bin-1", "target": "` + target.URL + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/replay", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		webui.replayHandler(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response replayResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, "base64", response.Encoding)
+		assert.Equal(t, "//4A", response.Body)
+	})
+
+	t.Run("rejects invalid target and method", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+		store.Add(requeststore.Request{ID: "bad-1", Method: "GET", URL: "/"})
+
+		for _, body := range []string{
+			`{"id": "bad-1", "target": "ftp://example.com"}`,
+			`{"id": "bad-1", "target": "localhost:8080"}`,
+			`{"id": "bad-1", "target": "http://localhost:8080", "method": "BAD METHOD"}`,
+		} {
+			req := httptest.NewRequest(http.MethodPost, "/api/replay", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			webui.replayHandler(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+	})
+
+	t.Run("returns bad gateway when debug server is unavailable", func(t *testing.T) {
+		store := requeststore.New(50)
+		// Point to a port that's not listening
+		webui := New(store, ":9003", "127.0.0.1:59999")
+
+		store.Add(requeststore.Request{
+			ID:     "replay-fail",
+			Method: "GET",
 			URL:    "/test",
 		})
 
@@ -393,3 +1054,1245 @@ func TestWebUI_replayHandler(t *testing.T) {
 		assert.Equal(t, http.StatusBadGateway, rec.Code)
 	})
 }
+
+func TestWebUI_bodyHandler(t *testing.T) {
+	t.Run("downloads in-memory body", func(t *testing.T) {
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{
+			ID:      "body-1",
+			Method:  "POST",
+			URL:     "/webhook",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    `{"a": 1}`,
+		})
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/body-1/body", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), `filename="body-1.body"`)
+		assert.Equal(t, `{"a": 1}`, rec.Body.String())
+	})
+
+	t.Run("downloads spooled body from disk", func(t *testing.T) {
+		spoolFile := filepath.Join(t.TempDir(), "body.bin")
+		require.NoError(t, os.WriteFile(spoolFile, []byte("complete body"), 0o600))
+
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{
+			ID:       "body-2",
+			Method:   "POST",
+			URL:      "/upload",
+			Body:     "comp",
+			BodyInfo: &requeststore.BodyInfo{Size: 13, Spooled: true, Path: spoolFile},
+		})
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/body-2/body", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "13", rec.Header().Get("Content-Length"))
+		assert.Equal(t, "complete body", rec.Body.String())
+	})
+
+	t.Run("returns no content for empty body", func(t *testing.T) {
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{ID: "body-3", Method: "GET", URL: "/"})
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/body-3/body", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("returns not found for unknown request ID", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/unknown/body", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("replays spooled body from disk", func(t *testing.T) {
+		var received string
+
+		debugServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received = string(body)
+			assert.Equal(t, int64(13), r.ContentLength)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer debugServer.Close()
+
+		spoolFile := filepath.Join(t.TempDir(), "body.bin")
+		require.NoError(t, os.WriteFile(spoolFile, []byte("complete body"), 0o600))
+
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{
+			ID:       "replay-spooled",
+			Method:   "POST",
+			URL:      "/upload",
+			Body:     "comp",
+			BodyInfo: &requeststore.BodyInfo{Size: 13, Spooled: true, Path: spoolFile},
+		})
+		webui := New(store, ":9003", strings.TrimPrefix(debugServer.URL, "http://"))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/replay", strings.NewReader(`{"id": "replay-spooled"}`))
+		rec := httptest.NewRecorder()
+
+		webui.replayHandler(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "complete body", received)
+	})
+}
+
+func TestWebUI_fileHandler(t *testing.T) {
+	savedFile := filepath.Join(t.TempDir(), "report.pdf")
+	require.NoError(t, os.WriteFile(savedFile, []byte("%PDF-1.4"), 0o600))
+
+	store := requeststore.New(50)
+	store.Add(requeststore.Request{
+		ID:     "files-1",
+		Method: "POST",
+		URL:    "/upload",
+		Files: []requeststore.FileAttachment{
+			{FieldName: "doc", Filename: "report.pdf", ContentType: "application/pdf", Size: 8, Path: savedFile},
+			{FieldName: "other", Filename: "skipped.bin", Size: 4},
+		},
+	})
+	webui := New(store, ":9003", ":9002")
+
+	t.Run("downloads saved file", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/files-1/files/0", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/pdf", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=report.pdf`, rec.Header().Get("Content-Disposition"))
+		assert.Equal(t, "8", rec.Header().Get("Content-Length"))
+		assert.Equal(t, "%PDF-1.4", rec.Body.String())
+	})
+
+	t.Run("returns not found for unsaved file", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/files-1/files/1", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("returns not found for invalid index", func(t *testing.T) {
+		for _, index := range []string{"2", "-1", "abc"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/requests/files-1/files/"+index, nil)
+			rec := httptest.NewRecorder()
+
+			webui.server.Handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code, index)
+		}
+	})
+
+	t.Run("returns not found for unknown request ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/unknown/files/0", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestWebUI_harHandler(t *testing.T) {
+	t.Run("exports all requests in chronological order", func(t *testing.T) {
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{ID: "first", Method: "GET", URL: "/a", Host: "localhost:9002"})
+		store.Add(requeststore.Request{ID: "second", Method: "POST", URL: "/b", Host: "localhost:9002", Body: "x"})
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/har", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), "requests.har")
+
+		var archive har.HAR
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &archive))
+		require.Len(t, archive.Log.Entries, 2)
+		assert.Equal(t, "http://localhost:9002/a", archive.Log.Entries[0].Request.URL)
+		assert.Equal(t, "http://localhost:9002/b", archive.Log.Entries[1].Request.URL)
+	})
+
+	t.Run("exports selected requests with spooled body", func(t *testing.T) {
+		spoolFile := filepath.Join(t.TempDir(), "body.bin")
+		require.NoError(t, os.WriteFile(spoolFile, []byte("complete body"), 0o600))
+
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{ID: "skip", Method: "GET", URL: "/"})
+		store.Add(requeststore.Request{
+			ID:       "big",
+			Method:   "POST",
+			URL:      "/upload",
+			Body:     "comp",
+			BodyInfo: &requeststore.BodyInfo{Size: 13, Spooled: true, Path: spoolFile},
+		})
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/har?id=big", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		var archive har.HAR
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &archive))
+		require.Len(t, archive.Log.Entries, 1)
+		assert.Equal(t, "complete body", archive.Log.Entries[0].Request.PostData.Text)
+	})
+
+	t.Run("exports preview of spooled body larger than inline limit", func(t *testing.T) {
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{
+			ID:       "huge",
+			Method:   "POST",
+			URL:      "/upload",
+			Body:     "comp",
+			BodyInfo: &requeststore.BodyInfo{Size: maxHARInlineBody + 1, Spooled: true, Path: "/nonexistent"},
+		})
+		webui := New(store, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/har?id=huge", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var archive har.HAR
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &archive))
+		require.Len(t, archive.Log.Entries, 1)
+		entry := archive.Log.Entries[0]
+		assert.Equal(t, "comp", entry.Request.PostData.Text)
+		assert.Equal(t, maxHARInlineBody+1, entry.Request.BodySize)
+		assert.Contains(t, entry.Request.PostData.Comment, "truncated")
+	})
+
+	t.Run("returns not found for unknown request ID", func(t *testing.T) {
+		webui := New(requeststore.New(50), ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/har?id=unknown", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("round trips through import", func(t *testing.T) {
+		source := requeststore.New(50)
+		source.Add(requeststore.Request{
+			Method:  "PUT",
+			URL:     "/items/1?force=true",
+			Host:    "localhost:9002",
+			Proto:   "HTTP/1.1",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    `{"name":"x"}`,
+		})
+		exportRec := httptest.NewRecorder()
+		New(source, ":9003", ":9002").server.Handler.ServeHTTP(
+			exportRec, httptest.NewRequest(http.MethodGet, "/api/har", nil),
+		)
+
+		target := requeststore.New(50)
+		webui := New(target, ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodPost, "/api/har", bytes.NewReader(exportRec.Body.Bytes()))
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var resp harImportResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 1, resp.Imported)
+		require.Len(t, resp.IDs, 1)
+
+		imported, ok := target.Get(resp.IDs[0])
+		require.True(t, ok)
+		original := source.GetAll()[0]
+		assert.Equal(t, original.Method, imported.Method)
+		assert.Equal(t, original.URL, imported.URL)
+		assert.Equal(t, original.Host, imported.Host)
+		assert.Equal(t, original.Headers, imported.Headers)
+		assert.Equal(t, original.Body, imported.Body)
+	})
+
+	t.Run("rejects invalid HAR", func(t *testing.T) {
+		webui := New(requeststore.New(50), ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodPost, "/api/har", strings.NewReader("{}"))
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		webui := New(requeststore.New(50), ":9003", ":9002")
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/har", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestWebUI_snippetHandler(t *testing.T) {
+	store := requeststore.New(50)
+	store.Add(requeststore.Request{
+		ID:      "snip-1",
+		Method:  "POST",
+		URL:     "/hook",
+		Host:    "localhost:9002",
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    `{"a":1}`,
+	})
+	webui := New(store, ":9003", ":9002")
+
+	t.Run("defaults to curl", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/snip-1/snippet", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+		assert.True(t, strings.HasPrefix(rec.Body.String(), "curl -X 'POST' 'http://localhost:9002/hook'"))
+	})
+
+	t.Run("returns requested format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/snip-1/snippet?format=python", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "import requests")
+	})
+
+	t.Run("rejects unknown format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/snip-1/snippet?format=cobol", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("returns not found for unknown request ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/unknown/snippet", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestWebUI_httpFileHandler(t *testing.T) {
+	store := requeststore.New(50)
+	store.Add(requeststore.Request{ID: "first", Method: "GET", URL: "/a", Host: "localhost:9002"})
+	store.Add(requeststore.Request{
+		ID:      "second",
+		Method:  "POST",
+		URL:     "/b",
+		Host:    "localhost:9002",
+		Headers: map[string]string{"Content-Type": "text/plain"},
+		Body:    "hello",
+	})
+	webui := New(store, ":9003", ":9002")
+
+	t.Run("exports all requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/http-file", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), "requests.http")
+		assert.Equal(t, `@host = localhost:9002
+
+### GET /a
+GET http://{{host}}/a HTTP/1.1
+
+### POST /b
+POST http://{{host}}/b HTTP/1.1
+Content-Type: text/plain
+
+hello
+`, rec.Body.String())
+	})
+
+	t.Run("exports selected requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/http-file?id=second", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "GET /a")
+		assert.Contains(t, rec.Body.String(), "### POST /b")
+	})
+
+	t.Run("exports binary bodies in a zip archive", func(t *testing.T) {
+		store.Add(requeststore.Request{
+			ID:     "binary",
+			Method: "PUT",
+			URL:    "/c",
+			Host:   "localhost:9002",
+			Body:   string([]byte{0x00, 0xFF}),
+		})
+		t.Cleanup(func() { store.Delete("binary") })
+
+		req := httptest.NewRequest(http.MethodGet, "/api/http-file?id=second&id=binary", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/zip", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), "requests.zip")
+
+		archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		require.NoError(t, err)
+
+		files := make(map[string]string)
+		for _, f := range archive.File {
+			rc, errOpen := f.Open()
+			require.NoError(t, errOpen)
+			data, errRead := io.ReadAll(rc)
+			require.NoError(t, errRead)
+			_ = rc.Close()
+			files[f.Name] = string(data)
+		}
+
+		require.Len(t, files, 2)
+		assert.Contains(t, files["requests.http"], "### POST /b")
+		assert.Contains(t, files["requests.http"], "< ./binary.body")
+		assert.Equal(t, string([]byte{0x00, 0xFF}), files["binary.body"])
+	})
+
+	t.Run("returns not found for unknown request ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/http-file?id=unknown", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestWebUI_sendHandler(t *testing.T) {
+	t.Run("sends json body to debug server path", func(t *testing.T) {
+		debugServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "/hooks?x=1", r.RequestURI)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.JSONEq(t, `{"a": 1}`, string(body))
+
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("OK"))
+		}))
+		defer debugServer.Close()
+
+		store := requeststore.New(50)
+		webui := New(store, ":9003", strings.TrimPrefix(debugServer.URL, "http://"))
+
+		body := `{
+			"method": "post",
+			"url": "/hooks?x=1",
+			"headers": {"Content-Type": "application/json"},
+			"body": "{\"a\": 1}"
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/api/send", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var sent requeststore.Request
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sent))
+		assert.NotEmpty(t, sent.ID)
+		assert.Equal(t, requeststore.DirectionOutbound, sent.Direction)
+		assert.Equal(t, debugServer.URL+"/hooks?x=1", sent.Target)
+		require.NotNil(t, sent.Response)
+		assert.Equal(t, http.StatusOK, sent.Response.Status)
+		assert.Equal(t, "OK", sent.Response.Body)
+
+		stored, ok := store.Get(sent.ID)
+		require.True(t, ok)
+		assert.Equal(t, "POST", stored.Method)
+		assert.Equal(t, time.UTC, stored.Time.Location())
+		assert.Equal(t, "/hooks?x=1", stored.URL)
+		assert.Equal(t, `{"a": 1}`, stored.Body)
+		assert.Equal(t, "OK", stored.Response.Body)
+	})
+
+	t.Run("sends multipart fields and files", func(t *testing.T) {
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+
+			assert.Equal(t, "bar", r.FormValue("foo"))
+			file, header, err := r.FormFile("upload")
+			require.NoError(t, err)
+			defer func() { _ = file.Close() }()
+
+			data, _ := io.ReadAll(file)
+			assert.Equal(t, "hello.txt", header.Filename)
+			assert.Equal(t, "text/plain", header.Header.Get("Content-Type"))
+			assert.Equal(t, "hello", string(data))
+
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer target.Close()
+
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		body := `{
+			"method": "POST",
+			"url": "` + target.URL + `/upload",
+			"fields": [{"name": "foo", "value": "bar"}],
+			"files": [{"field": "upload", "filename": "hello.txt", "contentType": "text/plain", "data": "aGVsbG8="}]
+		}`
+		req := httptest.NewRequest(http.MethodPost, "/api/send", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		webui.sendHandler(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var sent requeststore.Request
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sent))
+		assert.Equal(t, http.StatusCreated, sent.Response.Status)
+		assert.Contains(t, sent.Headers["Content-Type"], "multipart/form-data; boundary=")
+		require.Len(t, sent.Files, 1)
+		assert.Equal(t, "hello.txt", sent.Files[0].Filename)
+		assert.Equal(t, 5, sent.Files[0].Size)
+	})
+
+	t.Run("stores failed requests", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", "127.0.0.1:59999")
+
+		req := httptest.NewRequest(http.MethodPost, "/api/send", strings.NewReader(`{"url": "/down"}`))
+		rec := httptest.NewRecorder()
+
+		webui.sendHandler(rec, req)
+
+		assert.Equal(t, http.StatusBadGateway, rec.Code)
+		require.Equal(t, 1, store.Count())
+		stored := store.GetAll()[0]
+		assert.Equal(t, "GET", stored.Method)
+		require.NotNil(t, stored.Response)
+		assert.NotEmpty(t, stored.Response.Error)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		store := requeststore.New(50)
+		webui := New(store, ":9003", ":9002")
+
+		for _, body := range []string{
+			`invalid`,
+			`{"url": "example.com/a"}`,
+			`{"url": "/a", "body": "x", "fields": [{"name": "a", "value": "b"}]}`,
+			`{"url": "/a", "files": [{"field": "f", "filename": "a", "data": "!!"}]}`,
+		} {
+			req := httptest.NewRequest(http.MethodPost, "/api/send", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			webui.sendHandler(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/send", nil)
+		rec := httptest.NewRecorder()
+		webui.sendHandler(rec, req)
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Zero(t, store.Count())
+	})
+}
+
+func TestWebUI_diffHandler(t *testing.T) {
+	store := requeststore.New(50)
+	store.Add(requeststore.Request{
+		ID:      "a",
+		Method:  "POST",
+		URL:     "/hook",
+		Headers: map[string]string{"Date": "d1", "X-Event": "push"},
+		Body:    `{"id": 1, "ref": "main"}`,
+	})
+	store.Add(requeststore.Request{
+		ID:      "b",
+		Method:  "POST",
+		URL:     "/hook",
+		Headers: map[string]string{"Date": "d2", "X-Event": "push"},
+		Body:    `{"id": 2, "ref": "dev"}`,
+	})
+	webui := New(store, ":9003", ":9002")
+
+	t.Run("compares two requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/diff?left=a&right=b", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var result diff.Result
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.False(t, result.Equal)
+		assert.Len(t, result.Headers, 1)
+		assert.Equal(t, diff.ModeJSON, result.Body.Mode)
+		assert.Len(t, result.Body.Changes, 2)
+	})
+
+	t.Run("ignores volatile and given fields", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/diff?left=a&right=b&volatile=true&ignore=ref,x", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var result diff.Result
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.True(t, result.Equal)
+	})
+
+	t.Run("diffs preview of spooled bodies without reading them", func(t *testing.T) {
+		store.Add(requeststore.Request{
+			ID:       "big",
+			Body:     "head",
+			BodyInfo: &requeststore.BodyInfo{Size: 1 << 30, SHA256: "aaa", Spooled: true, Path: "/nonexistent"},
+		})
+		req := httptest.NewRequest(http.MethodGet, "/api/diff?left=a&right=big", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var result diff.Result
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+		assert.True(t, result.Body.Truncated)
+		assert.False(t, result.Body.Equal)
+	})
+
+	t.Run("returns not found for unknown request", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/diff?left=a&right=missing", nil)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestWebUI_bins(t *testing.T) {
+	t.Run("manages bins", func(t *testing.T) {
+		webui := New(requeststore.New(50), ":9003", ":9002", WithBins(bin.NewRegistry(1, 10)))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/bins", strings.NewReader(
+			`{"token":"alice","name":"Alice","secretToken":"t0ken","hmacSecret":"s3cr3t"}`,
+		))
+		rec := httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `"http://localhost:9002/b/alice/"`, jsonField(t, rec.Body.Bytes(), "url"))
+		assert.JSONEq(t, `"/bins/alice/"`, jsonField(t, rec.Body.Bytes(), "dashboard"))
+		assert.JSONEq(t, `"t0ken"`, jsonField(t, rec.Body.Bytes(), "secretToken"))
+		assert.JSONEq(t, `"s3cr3t"`, jsonField(t, rec.Body.Bytes(), "hmacSecret"))
+
+		for body, status := range map[string]int{
+			`{"token":"alice"}`:   http.StatusConflict,
+			`{"token":"bob"}`:     http.StatusInsufficientStorage,
+			`{"token":"B A D"}`:   http.StatusBadRequest,
+			`{"token":["alice"]}`: http.StatusBadRequest,
+		} {
+			req = httptest.NewRequest(http.MethodPost, "/api/bins", strings.NewReader(body))
+			rec = httptest.NewRecorder()
+			webui.server.Handler.ServeHTTP(rec, req)
+			assert.Equal(t, status, rec.Code, body)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/bins", nil)
+		rec = httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		var bins []map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &bins))
+		require.Len(t, bins, 1)
+		assert.Equal(t, "Alice", bins[0]["name"])
+		assert.Contains(t, rec.Body.String(), `"hasSecretToken":true,"hasHMACSecret":true`)
+		assert.NotContains(t, rec.Body.String(), "t0ken")
+		assert.NotContains(t, rec.Body.String(), "s3cr3t")
+
+		req = httptest.NewRequest(http.MethodGet, "/api/bins/alice", nil)
+		rec = httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "t0ken")
+		assert.NotContains(t, rec.Body.String(), "s3cr3t")
+
+		req = httptest.NewRequest(http.MethodDelete, "/api/bins/alice", nil)
+		rec = httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/bins/alice", nil)
+		rec = httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("scopes api to bin store", func(t *testing.T) {
+		store := requeststore.New(50)
+		store.Add(requeststore.Request{ID: "default-1"})
+		bins := bin.NewRegistry(10, 10)
+		b, err := bins.Create(bin.Config{Token: "alice"})
+		require.NoError(t, err)
+		b.Store.Add(requeststore.Request{ID: "alice-1", Bin: "alice"})
+		webui := New(store, ":9003", ":9002", WithBins(bins))
+
+		req := httptest.NewRequest(http.MethodGet, "/bins/alice/api/requests", nil)
+		rec := httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "alice-1")
+		assert.NotContains(t, rec.Body.String(), "default-1")
+
+		req = httptest.NewRequest(http.MethodDelete, "/bins/alice/api/requests/alice-1", nil)
+		rec = httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, 0, b.Store.Count())
+		assert.Equal(t, 1, store.Count())
+
+		req = httptest.NewRequest(http.MethodGet, "/bins/alice/", nil)
+		rec = httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+
+		req = httptest.NewRequest(http.MethodGet, "/bins/missing/api/requests", nil)
+		rec = httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("replays bin requests into bin", func(t *testing.T) {
+		var gotPath string
+		debugServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer debugServer.Close()
+
+		bins := bin.NewRegistry(10, 10)
+		b, err := bins.Create(bin.Config{Token: "alice"})
+		require.NoError(t, err)
+		b.Store.Add(requeststore.Request{ID: "alice-1", Method: "GET", URL: "/hooks", Bin: "alice"})
+		webui := New(requeststore.New(50), ":9003", strings.TrimPrefix(debugServer.URL, "http://"), WithBins(bins))
+
+		req := httptest.NewRequest(http.MethodPost, "/bins/alice/api/replay", strings.NewReader(`{"id":"alice-1"}`))
+		rec := httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "/b/alice/hooks", gotPath)
+	})
+
+	t.Run("returns not found when bins are disabled", func(t *testing.T) {
+		webui := New(requeststore.New(50), ":9003", ":9002")
+
+		for _, path := range []string{"/api/bins", "/api/bins/alice", "/bins/alice/api/requests"} {
+			rec := httptest.NewRecorder()
+			webui.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusNotFound, rec.Code, path)
+		}
+	})
+}
+
+func jsonField(t *testing.T, data []byte, key string) string {
+	t.Helper()
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+
+	return string(fields[key])
+}
+
+func TestWebUI_auth(t *testing.T) {
+	store := requeststore.New(50)
+	store.Add(requeststore.Request{ID: "one", Method: "POST", URL: "/hook"})
+	webui := New(store, ":9003", ":9002", WithCredentials(
+		Credential{Username: "admin", Password: "secret"},
+		Credential{Username: "viewer", Password: "secret", Role: RoleReadOnly},
+		Credential{Token: "admin-token"},
+		Credential{Token: "viewer-token", Role: RoleReadOnly},
+	))
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	t.Run("requires credentials on all routes", func(t *testing.T) {
+		for _, target := range []string{"/", "/events", "/api/requests", "/api/requests/one", "/api/bins"} {
+			rec := serve(httptest.NewRequest(http.MethodGet, target, nil))
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code, target)
+			assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Basic")
+		}
+	})
+
+	t.Run("rejects wrong credentials", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests", nil)
+		req.SetBasicAuth("admin", "wrong")
+		assert.Equal(t, http.StatusUnauthorized, serve(req).Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/requests", nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+		assert.Equal(t, http.StatusUnauthorized, serve(req).Code)
+	})
+
+	t.Run("accepts basic auth and bearer token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests/one", nil)
+		req.SetBasicAuth("admin", "secret")
+		assert.Equal(t, http.StatusOK, serve(req).Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/requests/one", nil)
+		req.Header.Set("Authorization", "Bearer viewer-token")
+		assert.Equal(t, http.StatusOK, serve(req).Code)
+	})
+
+	t.Run("query token sets cookie", func(t *testing.T) {
+		rec := serve(httptest.NewRequest(http.MethodGet, "/?access_token=admin-token", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.True(t, cookies[0].HttpOnly)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/session", nil)
+		req.AddCookie(cookies[0])
+		rec = serve(req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"role":"admin"}`, rec.Body.String())
+	})
+
+	t.Run("read-only can view but not change", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/session", nil)
+		req.SetBasicAuth("viewer", "secret")
+		rec := serve(req)
+		assert.JSONEq(t, `{"role":"read-only"}`, rec.Body.String())
+
+		for _, tc := range []struct{ method, target string }{
+			{http.MethodDelete, "/api/requests/one"},
+			{http.MethodDelete, "/api/requests"},
+			{http.MethodPatch, "/api/requests/one"},
+			{http.MethodPost, "/api/replay"},
+			{http.MethodPost, "/api/send"},
+			{http.MethodPost, "/api/har"},
+		} {
+			req = httptest.NewRequest(tc.method, tc.target, strings.NewReader(`{}`))
+			req.Header.Set("Authorization", "Bearer viewer-token")
+
+			assert.Equal(t, http.StatusForbidden, serve(req).Code, tc.method+" "+tc.target)
+		}
+		assert.Equal(t, 1, store.Count())
+	})
+
+	t.Run("admin can delete", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/requests/one", nil)
+		req.SetBasicAuth("admin", "secret")
+
+		assert.Equal(t, http.StatusNoContent, serve(req).Code)
+		assert.Equal(t, 0, store.Count())
+	})
+}
+
+func TestWebUI_cors(t *testing.T) {
+	store := requeststore.New(50)
+	store.Add(requeststore.Request{ID: "one", Method: "POST", URL: "/hook"})
+
+	t.Run("no cors headers by default", func(t *testing.T) {
+		webui := New(store, ":9003", ":9002")
+		req := httptest.NewRequest(http.MethodGet, "/api/requests", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	webui := New(store, ":9003", ":9002", WithCORSOrigins("https://app.example.com"))
+
+	t.Run("allows configured origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/requests", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("answers preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/requests/one", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), http.MethodDelete)
+	})
+
+	t.Run("blocks cross-origin state change", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/requests/one", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, 1, store.Count())
+	})
+
+	t.Run("allows state change from configured origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/requests/one", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		rec := httptest.NewRecorder()
+
+		webui.server.Handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	})
+
+	t.Run("invalid origin fails start and handler", func(t *testing.T) {
+		webui := New(store, ":0", ":9002", WithCORSOrigins("app.example.com/path"))
+
+		require.Error(t, webui.Start())
+
+		webui = New(store, ":9002", ":9002", WithPrefix("/__debugger"), WithCORSOrigins("app.example.com/path"))
+		_, err := webui.Handler()
+		require.Error(t, err)
+	})
+}
+
+func TestParseBasicCredential(t *testing.T) {
+	c, err := ParseBasicCredential("user:pa:ss", RoleReadOnly)
+	require.NoError(t, err)
+	assert.Equal(t, Credential{Username: "user", Password: "pa:ss", Role: RoleReadOnly}, c)
+
+	for _, s := range []string{"user", "user:", ":pass"} {
+		_, err = ParseBasicCredential(s, RoleAdmin)
+		require.ErrorIs(t, err, ErrInvalidCredential, s)
+	}
+}
+
+func TestParseCORSOrigins(t *testing.T) {
+	origins, err := ParseCORSOrigins(" https://a.example.com, ,http://localhost:3000,*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://a.example.com", "http://localhost:3000", "*"}, origins)
+
+	_, err = ParseCORSOrigins("https://a.example.com/path")
+	require.Error(t, err)
+}
+
+func TestWebUI_waitHandler(t *testing.T) {
+	store := requeststore.New(50)
+	store.Add(requeststore.Request{ID: "old", Method: "POST", URL: "/hook", Body: `{"action": "opened"}`})
+	webui := New(store, ":9003", ":9002")
+
+	wait := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		return rec
+	}
+
+	t.Run("waits for a new matching request", func(t *testing.T) {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- wait("/api/wait?method=POST&json=$.action=closed&timeout=5s") }()
+
+		require.Eventually(t, func() bool { return store.Stats().Listeners > 0 }, time.Second, time.Millisecond)
+		store.Add(requeststore.Request{ID: "other", Method: "POST", URL: "/hook", Body: `{"action": "opened"}`})
+		store.Add(requeststore.Request{
+			ID: "sent", Method: "POST", URL: "/hook", Body: `{"action": "closed"}`,
+			Direction: requeststore.DirectionOutbound,
+		})
+		store.Add(requeststore.Request{ID: "match", Method: "POST", URL: "/hook", Body: `{"action": "closed"}`})
+
+		rec := <-done
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var req requeststore.Request
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &req))
+		assert.Equal(t, "match", req.ID)
+	})
+
+	t.Run("returns stored requests after given sequence number", func(t *testing.T) {
+		rec := wait("/api/wait?json=$.action=opened&after=0")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"id":"old"`)
+
+		rec = wait("/api/wait?json=$.action=opened&after=1")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"id":"other"`)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		rec := wait("/api/wait?method=DELETE&after=0&timeout=50ms")
+		assert.Equal(t, http.StatusRequestTimeout, rec.Code)
+		assert.Contains(t, rec.Body.String(), "no matching request within 50ms")
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		for _, target := range []string{
+			"/api/wait?timeout=soon",
+			"/api/wait?timeout=-1s",
+			"/api/wait?timeout=1h",
+			"/api/wait?after=x",
+			"/api/wait?pathRegex=(",
+		} {
+			assert.Equal(t, http.StatusBadRequest, wait(target).Code, target)
+		}
+
+		rec := httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/wait", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestWebUI_expectationsHandler(t *testing.T) {
+	store := requeststore.New(50)
+	store.Add(requeststore.Request{ID: "before", Method: "POST", URL: "/hooks/github"})
+	webui := New(store, ":9003", ":9002")
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+		return rec
+	}
+
+	report := func(target string) expectationReport {
+		rec := serve(http.MethodGet, target, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var r expectationReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &r))
+
+		return r
+	}
+
+	t.Run("reports met without expectations", func(t *testing.T) {
+		r := report("/api/expectations")
+		assert.True(t, r.Met)
+		assert.Empty(t, r.Expectations)
+		assert.Empty(t, r.Unexpected)
+	})
+
+	t.Run("reports met, missed and unexpected requests", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/api/expectations", `[
+			{"name": "github", "match": {"method": "POST", "path": "/hooks/github"}, "count": 2},
+			{"name": "stripe", "match": {"path": "/hooks/stripe", "header": ["Stripe-Signature"]}}
+		]`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"after":1`)
+
+		store.Add(requeststore.Request{ID: "gh-1", Method: "POST", URL: "/hooks/github"})
+		store.Add(requeststore.Request{ID: "unknown", Method: "GET", URL: "/favicon.ico"})
+		store.Add(requeststore.Request{ID: "gh-2", Method: "POST", URL: "/hooks/github"})
+		store.Add(requeststore.Request{ID: "sent", Method: "GET", URL: "/", Direction: requeststore.DirectionOutbound})
+
+		r := report("/api/expectations")
+		assert.False(t, r.Met)
+		require.Len(t, r.Expectations, 2)
+		assert.Equal(t, expectationMet, r.Expectations[0].Status)
+		assert.Equal(t, []string{"gh-1", "gh-2"}, r.Expectations[0].Matched)
+		assert.Equal(t, expectationMissed, r.Expectations[1].Status)
+		assert.Empty(t, r.Expectations[1].Matched)
+		assert.Equal(t, []string{"unknown"}, r.Unexpected)
+	})
+
+	t.Run("waits until expectations are met", func(t *testing.T) {
+		done := make(chan expectationReport)
+		go func() { done <- report("/api/expectations?wait=5s") }()
+
+		require.Eventually(t, func() bool { return store.Stats().Listeners > 0 }, time.Second, time.Millisecond)
+		store.Add(requeststore.Request{
+			ID:      "stripe",
+			Method:  "POST",
+			URL:     "/hooks/stripe",
+			Headers: map[string]string{"Stripe-Signature": "t=1"},
+		})
+
+		r := <-done
+		assert.True(t, r.Met)
+		assert.Equal(t, []string{"stripe"}, r.Expectations[1].Matched)
+	})
+
+	t.Run("wait expires with the current report", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, serve(http.MethodPost, "/api/expectations", `{"match": {"method": "PUT"}}`).Code)
+
+		r := report("/api/expectations?wait=50ms")
+		assert.False(t, r.Met)
+		assert.Equal(t, expectationMissed, r.Expectations[2].Status)
+	})
+
+	t.Run("resets expectations", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/api/expectations", "").Code)
+		assert.Empty(t, report("/api/expectations").Expectations)
+	})
+
+	t.Run("sent requests do not meet expectations", func(t *testing.T) {
+		debugServer := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+		defer debugServer.Close()
+
+		store := requeststore.New(50)
+		webui := New(store, ":9003", strings.TrimPrefix(debugServer.URL, "http://"))
+		serve := func(method, target, body string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			webui.server.Handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+			return rec
+		}
+
+		require.Equal(t, http.StatusCreated,
+			serve(http.MethodPost, "/api/expectations", `{"match": {"method": "POST", "path": "/hooks"}}`).Code)
+		require.Equal(t, http.StatusOK, serve(http.MethodPost, "/api/send", `{"method": "POST", "url": "/hooks"}`).Code)
+		require.Equal(t, 1, store.Count())
+
+		rec := serve(http.MethodGet, "/api/expectations", "")
+		var r expectationReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &r))
+		assert.False(t, r.Met)
+		assert.Empty(t, r.Expectations[0].Matched)
+		assert.Empty(t, r.Unexpected)
+	})
+
+	t.Run("rejects invalid expectations", func(t *testing.T) {
+		for _, body := range []string{
+			``,
+			`[]`,
+			`[null]`,
+			`{"match": {"method": 1}}`,
+			`{"match": {"pathRegex": "("}}`,
+			`{"match": {}, "count": -1}`,
+		} {
+			assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/api/expectations", body).Code, body)
+		}
+
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/api/expectations?wait=x", "").Code)
+		assert.Equal(t, http.StatusMethodNotAllowed, serve(http.MethodPut, "/api/expectations", "").Code)
+	})
+
+	t.Run("keeps expectations per bin", func(t *testing.T) {
+		webui = New(requeststore.New(50), ":9003", ":9002", WithBins(bin.NewRegistry(1, 10)))
+		require.Equal(t, http.StatusCreated, serve(http.MethodPost, "/api/bins", `{"token": "alice"}`).Code)
+		require.Equal(t, http.StatusCreated, serve(http.MethodPost, "/bins/alice/api/expectations", `{"match": {}}`).Code)
+
+		assert.Empty(t, report("/api/expectations").Expectations)
+		assert.Len(t, report("/bins/alice/api/expectations").Expectations, 1)
+
+		require.Equal(t, http.StatusNoContent, serve(http.MethodDelete, "/api/bins/alice", "").Code)
+		assert.Empty(t, webui.expectations)
+	})
+}
+
+func TestWebUI_metricsHandler(t *testing.T) {
+	t.Run("returns 404 without metrics", func(t *testing.T) {
+		webui := New(requeststore.New(50), ":9003", ":9002")
+
+		rec := httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("writes request and store metrics", func(t *testing.T) {
+		collector := metrics.New()
+		collector.ObserveRequest(http.MethodPost, "/hook", http.StatusOK, 10, time.Millisecond)
+
+		store := requeststore.New(2)
+		for range 3 {
+			store.Add(requeststore.Request{Method: http.MethodPost})
+		}
+		store.Subscribe() // waiters are not streams
+
+		webui := New(store, ":9003", ":9002", WithMetrics(collector), WithBins(bin.NewRegistry(1, 10)))
+		streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+		streamEvents(t, webui, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+		rec := httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, metrics.ContentType, rec.Header().Get("Content-Type"))
+		for _, line := range []string{
+			`basichttpdebugger_requests_total{method="POST",path="/hook",status="200"} 1`,
+			"basichttpdebugger_store_requests 2",
+			"basichttpdebugger_store_max_requests 2",
+			"basichttpdebugger_store_evictions_total 1",
+			"basichttpdebugger_sse_subscribers 2",
+			"basichttpdebugger_bins 0",
+		} {
+			assert.Contains(t, rec.Body.String(), line+"\n")
+		}
+
+		rec = httptest.NewRecorder()
+		webui.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
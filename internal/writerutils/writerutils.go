@@ -13,3 +13,15 @@ func GetFilePathName(w io.Writer) string {
 
 	return ""
 }
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// NopCloser returns a WriteCloser with a no-op Close method wrapping w, e.g.
+// to use os.Stderr or a buffer as output without closing it.
+func NopCloser(w io.Writer) io.WriteCloser {
+	return nopCloser{w}
+}
@@ -54,3 +54,21 @@ func TestGetFilePathName(t *testing.T) {
 		})
 	}
 }
+
+func TestNopCloser(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := writerutils.NopCloser(&buf)
+	if _, err := io.WriteString(w, "hello"); err != nil {
+		t.Fatalf("write error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close() = %v, want nil", err)
+	}
+	if buf.String() != "hello" {
+		t.Errorf("written = %q, want %q", buf.String(), "hello")
+	}
+	if got := writerutils.GetFilePathName(w); got != "" {
+		t.Errorf("GetFilePathName() = %q, want empty", got)
+	}
+}
@@ -2,12 +2,22 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/vbyazilim/basichttpdebugger/internal/httpserver"
+	"github.com/vbyazilim/basichttpdebugger/internal/replay"
 )
 
 func main() {
-	if err := httpserver.Run(); err != nil {
+	var err error
+
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		err = replay.Run(os.Args[2:])
+	} else {
+		err = httpserver.Run()
+	}
+
+	if err != nil {
 		log.Fatal(err)
 	}
 }